@@ -0,0 +1,45 @@
+package main
+
+import (
+	"banner-serivce/internal/auth/jwt"
+	"banner-serivce/internal/config"
+	"fmt"
+	"os"
+)
+
+// defaultKeyID names the key built from jwt.secret when no key set is
+// configured.
+const defaultKeyID = "default"
+
+// loadKeySet builds the JWT key set from the config, reading PEM files for
+// asymmetric keys.
+func loadKeySet(cfg config.JWTCfg) (*jwt.KeySet, error) {
+	if len(cfg.Keys) == 0 {
+		key, err := jwt.NewHMACKey(defaultKeyID, []byte(cfg.Secret))
+		if err != nil {
+			return nil, err
+		}
+		return jwt.NewKeySet(defaultKeyID, key)
+	}
+
+	keys := make([]*jwt.Key, 0, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		var key *jwt.Key
+		var err error
+		if keyCfg.Algorithm == jwt.AlgHS256 {
+			key, err = jwt.NewHMACKey(keyCfg.ID, []byte(keyCfg.Secret))
+		} else {
+			var pemBytes []byte
+			pemBytes, err = os.ReadFile(keyCfg.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", keyCfg.ID, err)
+			}
+			key, err = jwt.ParsePEMKey(keyCfg.ID, keyCfg.Algorithm, pemBytes)
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return jwt.NewKeySet(cfg.SigningKey, keys...)
+}
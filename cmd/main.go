@@ -2,21 +2,34 @@ package main
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/audit"
 	"banner-serivce/internal/auth/jwt"
+	"banner-serivce/internal/banners"
+	"banner-serivce/internal/cache"
 	"banner-serivce/internal/config"
 	"banner-serivce/internal/crud"
 	"banner-serivce/internal/db/postgresql"
+	audithandlers "banner-serivce/internal/handlers/audit_handlers"
 	bannerhandlers "banner-serivce/internal/handlers/banner_handlers"
 	featurehandlers "banner-serivce/internal/handlers/feature_handlers"
+	healthhandlers "banner-serivce/internal/handlers/health_handlers"
+	jobhandlers "banner-serivce/internal/handlers/job_handlers"
 	taghandlers "banner-serivce/internal/handlers/tag_handlers"
 	userhandlers "banner-serivce/internal/handlers/user_handlers"
+	"banner-serivce/internal/health"
+	"banner-serivce/internal/jobs"
+	"banner-serivce/internal/metrics"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 const (
@@ -27,17 +40,19 @@ const (
 
 func main() {
 	cfg := config.MustLoad()
-	fmt.Println(cfg)
-	fmt.Println(cfg.Env)
 	log := setupLogger(cfg.Env)
 	log.Debug("debug messages are active")
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, log, os.Args[2:]))
+	}
+
+	log.Info("connecting to postgres")
 	pg, err := connectToPostgres(cfg, log)
 	if err != nil {
 		log.Error("failed to create postgres db", errMsg.Err(err))
 		os.Exit(1)
 	}
-	fmt.Println("connecting to postgres...")
-	defer pg.Close()
 
 	if pg == nil {
 		log.Error("failed to connect to postgres")
@@ -49,52 +64,117 @@ func main() {
 	} else {
 		log.Info("postgres db connected successfully")
 	}
+
+	if err := pg.Migrate(context.Background()); err != nil {
+		log.Error("failed to migrate postgres db", errMsg.Err(err))
+		os.Exit(1)
+	}
+	if err := pg.BootstrapAdmin(context.Background(), cfg.Env == ProdEnv); err != nil {
+		log.Error("failed to bootstrap admin", errMsg.Err(err))
+		os.Exit(1)
+	}
 	log.Info("application started", slog.String("env", cfg.Env))
 
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
 	router.Use(middleware.RealIP)
 	router.Use(middleware.Logger)
+	router.Use(metrics.Middleware)
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.URLFormat)
 
 	fr := crud.NewFeatureRepository(pg.Db, log)
 	tr := crud.NewTagRepository(pg.Db, log)
 	ur := crud.NewUserRepository(pg.Db, log)
-	br := crud.NewBannerRepository(pg.Db, log)
-	btr := crud.NewBannerTagRepository(pg.Db, log)
-	jwtManager := jwt.NewJWTManager(cfg.JWT.Secret, log)
-
-	router.Post("/users", userhandlers.New(log, ur))
-	router.Post("/login", userhandlers.LoginFunc(log, ur, jwtManager))
-
-	router.With(func(next http.Handler) http.Handler {
-		return jwt.TokenAuthMiddleware(jwtManager, next)
-	}).Get("/user_banner", bannerhandlers.NewGetBannerHandler(log, br))
-
-	router.With(func(next http.Handler) http.Handler {
-		return jwt.TokenAuthMiddleware(jwtManager, next)
-	}).Post("/tags", taghandlers.New(log, tr))
-
-	router.With(func(next http.Handler) http.Handler {
-		return jwt.TokenAuthMiddleware(jwtManager, next)
-	}).Post("/banners", bannerhandlers.New(log, br, btr))
-
-	router.With(func(next http.Handler) http.Handler {
-		return jwt.TokenAuthMiddleware(jwtManager, next)
-	}).Post("/features", featurehandlers.New(log, fr))
-
-	router.With(func(next http.Handler) http.Handler {
-		return jwt.TokenAuthMiddleware(jwtManager, next)
-	}).Delete("/banner/{id}", bannerhandlers.NewDeleteBannerHandler(log, br))
-
-	router.With(func(next http.Handler) http.Handler {
-		return jwt.TokenAuthMiddleware(jwtManager, next)
-	}).Get("/banner", bannerhandlers.NewGetBannersHandler(br, log))
-
-	router.With(func(next http.Handler) http.Handler {
-		return jwt.TokenAuthMiddleware(jwtManager, next)
-	}).Patch("/banner/{id}", bannerhandlers.NewUpdateBannerHandler(br, log))
+	br := crud.NewBannerRepository(pg.Db, log, cfg.Revisions.Keep)
+	bc := cache.NewBannerCache(br, cfg.Cache.TTL, cfg.Cache.MaxEntries)
+	ar := crud.NewAuditRepository(pg.Db, log)
+	auditor := audit.NewRecorder(ar, log)
+	tokens := crud.NewTokenRepository(pg.Db, log)
+	denyList := jwt.NewDenyList(tokens, log)
+	if err := denyList.Sync(context.Background()); err != nil {
+		log.Error("failed to load token deny-list", errMsg.Err(err))
+		os.Exit(1)
+	}
+	keys, err := loadKeySet(cfg.JWT)
+	if err != nil {
+		log.Error("failed to load jwt keys", errMsg.Err(err))
+		os.Exit(1)
+	}
+	jwtManager := jwt.NewJWTManager(keys, denyList, log)
+	tokenTTL := userhandlers.TokenTTL{Access: cfg.JWT.AccessTTL, Refresh: cfg.JWT.RefreshTTL}
+	bannerEditor := banners.NewEditor(br, bc, auditor)
+	jobManager := jobs.NewManager(crud.NewJobRepository(pg.Db, log), log, cfg.Jobs.Workers, cfg.Jobs.PollInterval, cfg.Jobs.Lease, cfg.Jobs.Retention)
+	jobManager.Register(bannerhandlers.DeleteBannersJob, bannerhandlers.NewDeleteBannersJob(bannerEditor))
+	jobManager.Start()
+
+	metrics.Register(metrics.NewPoolCollector(pg.Db))
+	metrics.Register(metrics.NewCacheCollectors("user_banner", bc)...)
+	router.Handle("/metrics", metrics.Handler())
+
+	probe := health.NewProbe(cfg.Health.CheckTimeout)
+	probe.AddCheck("postgres", pg.Ping)
+	probe.AddCheck("cache", bc.Ping)
+	router.Get("/healthz", healthhandlers.NewLivenessHandler())
+	router.Get("/readyz", healthhandlers.NewReadinessHandler(log, probe))
+
+	router.Post("/users", userhandlers.New(log, ur, auditor))
+	router.Post("/login", userhandlers.LoginFunc(log, ur, tokens, jwtManager, tokenTTL))
+	router.Post("/token/refresh", userhandlers.NewRefreshTokenHandler(log, ur, tokens, jwtManager, tokenTTL))
+	router.Post("/logout", userhandlers.NewLogoutHandler(log, tokens, jwtManager))
+	router.Get("/.well-known/jwks.json", userhandlers.NewJWKSHandler(jwtManager))
+
+	router.Group(func(r chi.Router) {
+		r.Use(jwt.RequirePermission(jwtManager, jwt.PermReadUserBanner))
+		r.Get("/user_banner", bannerhandlers.NewGetBannerHandler(log, bc, cfg.UserBanner.CacheControl))
+		r.Post("/user_banner/batch", bannerhandlers.NewGetBannersBatchHandler(log, bc))
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(jwt.RequirePermission(jwtManager, jwt.PermManageTags))
+		r.Post("/tags", taghandlers.New(log, tr, auditor))
+		r.Get("/tags", taghandlers.NewGetTagsHandler(log, tr))
+		r.Get("/tags/{id}", taghandlers.NewGetTagHandler(log, tr))
+		r.Patch("/tags/{id}", taghandlers.NewUpdateTagHandler(log, tr, auditor))
+		r.Delete("/tags/{id}", taghandlers.NewDeleteTagHandler(log, tr, bannerEditor, auditor))
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(jwt.RequirePermission(jwtManager, jwt.PermManageFeatures))
+		r.Post("/features", featurehandlers.New(log, fr, auditor))
+		r.Get("/features", featurehandlers.NewGetFeaturesHandler(log, fr))
+		r.Get("/features/{id}", featurehandlers.NewGetFeatureHandler(log, fr))
+		r.Patch("/features/{id}", featurehandlers.NewUpdateFeatureHandler(log, fr, auditor))
+		r.Delete("/features/{id}", featurehandlers.NewDeleteFeatureHandler(log, fr, bannerEditor, auditor))
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(jwt.RequirePermission(jwtManager, jwt.PermManageBanners))
+		r.Post("/banners", bannerhandlers.New(log, br, auditor))
+		r.Get("/banner", bannerhandlers.NewGetBannersHandler(br, log))
+		r.Get("/banner/{id}", bannerhandlers.NewGetBannerByIDHandler(log, br))
+		r.Patch("/banner/{id}", bannerhandlers.NewUpdateBannerHandler(br, log, bannerEditor))
+		r.Delete("/banner/{id}", bannerhandlers.NewDeleteBannerHandler(log, bannerEditor))
+		r.Delete("/banner", bannerhandlers.NewDeleteBannersHandler(log, jobManager))
+		r.Get("/jobs/{id}", jobhandlers.NewGetJobHandler(log, jobManager))
+		r.Get("/banner/{id}/versions", bannerhandlers.NewGetBannerVersionsHandler(log, br))
+		r.Post("/banner/{id}/versions/{version}/activate", bannerhandlers.NewActivateBannerVersionHandler(log, bannerEditor))
+		r.Get("/banner/{id}/variants", bannerhandlers.NewGetBannerVariantsHandler(log, br))
+		r.Put("/banner/{id}/variants", bannerhandlers.NewReplaceBannerVariantsHandler(log, bannerEditor))
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(jwt.RequirePermission(jwtManager, jwt.PermManageUsers))
+		r.Get("/users", userhandlers.NewGetUsersHandler(log, ur))
+		r.Get("/users/{id}", userhandlers.NewGetUserHandler(log, ur))
+		r.Patch("/users/{id}", userhandlers.NewUpdateUserHandler(log, ur, jwtManager, tokenTTL, auditor))
+		r.Delete("/users/{id}", userhandlers.NewDeleteUserHandler(log, ur, jwtManager, tokenTTL, auditor))
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(jwt.RequirePermission(jwtManager, jwt.PermReadAudit))
+		r.Get("/audit", audithandlers.NewGetAuditEventsHandler(log, ar))
+	})
 
 	log.Info("starting server", slog.String("addr", cfg.HTTPServer.Addr))
 	server := &http.Server{
@@ -105,10 +185,38 @@ func main() {
 		IdleTimeout:       cfg.HTTPServer.IdleTimeout,
 	}
 
-	if err := server.ListenAndServe(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go denyList.Run(ctx, cfg.JWT.DenyListSync)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		log.Info("shutdown signal received")
+		probe.Drain()
+		log.Info("readiness switched off, waiting before draining", slog.Duration("delay", cfg.Health.DrainDelay))
+		time.Sleep(cfg.Health.DrainDelay)
+	case err := <-serverErr:
 		log.Error("failed to start server", errMsg.Err(err))
 	}
 
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to drain http server", errMsg.Err(err))
+	}
+	if err := jobManager.Stop(shutdownCtx); err != nil {
+		log.Error("failed to stop background jobs", errMsg.Err(err))
+	}
+	pg.Close()
+	log.Info("server stopped")
 }
 
 func setupLogger(env string) *slog.Logger {
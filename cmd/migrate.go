@@ -0,0 +1,72 @@
+package main
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/config"
+	"banner-serivce/internal/db/postgresql"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: banner-service migrate up|down|status"
+
+// runMigrate handles the migrate subcommand and returns the process exit code.
+func runMigrate(cfg *config.Config, log *slog.Logger, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	pg, err := connectToPostgres(cfg, log)
+	if err != nil {
+		log.Error("failed to create postgres db", errMsg.Err(err))
+		return 1
+	}
+	defer pg.Close()
+
+	migrator, err := postgresql.NewMigrator(pg.Db, log)
+	if err != nil {
+		log.Error("failed to load migrations", errMsg.Err(err))
+		return 1
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx)
+	case "status":
+		err = printMigrationStatus(ctx, migrator)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	if err != nil {
+		log.Error("migration failed", errMsg.Err(err))
+		return 1
+	}
+	return 0
+}
+
+func printMigrationStatus(ctx context.Context, migrator *postgresql.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return tw.Flush()
+}
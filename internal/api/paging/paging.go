@@ -0,0 +1,37 @@
+package paging
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+// Page selects a slice of a list endpoint's results.
+type Page struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// Parse reads the limit and offset query parameters. A missing limit is
+// defaultLimit; a limit above maxLimit is refused unless maxLimit is zero.
+func Parse(query url.Values, defaultLimit, maxLimit int) (Page, error) {
+	page := Page{Limit: defaultLimit}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 || (maxLimit > 0 && limit > maxLimit) {
+			return Page{}, errors.New("invalid limit")
+		}
+		page.Limit = limit
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return Page{}, errors.New("invalid offset")
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
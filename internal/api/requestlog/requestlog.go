@@ -0,0 +1,23 @@
+package requestlog
+
+import (
+	"banner-serivce/internal/auth/jwt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// New returns the logger a handler uses for one request. It names the handler
+// in options and adds the request id and, when the request is authenticated,
+// the username of the caller.
+func New(log *slog.Logger, r *http.Request, options string) *slog.Logger {
+	attrs := []any{
+		slog.String("options", options),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	}
+	if principal, ok := jwt.PrincipalFromContext(r.Context()); ok {
+		attrs = append(attrs, slog.String("username", principal.Username))
+	}
+	return log.With(attrs...)
+}
@@ -0,0 +1,101 @@
+package audit
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/auth/jwt"
+	"banner-serivce/internal/structs"
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	EntityBanner  = "banner"
+	EntityTag     = "tag"
+	EntityFeature = "feature"
+	EntityUser    = "user"
+)
+
+const (
+	ActionCreate          = "create"
+	ActionUpdate          = "update"
+	ActionDelete          = "delete"
+	ActionBulkDelete      = "bulk_delete"
+	ActionActivateVersion = "activate_version"
+	ActionReplaceVariants = "replace_variants"
+)
+
+// anonymousActor is recorded for changes made without a token.
+const anonymousActor = "anonymous"
+
+// Event describes a change to record. Actor defaults to the caller stored in
+// the request context. Before and After are marshalled to JSON; leave them nil
+// when the entity did not exist on that side of the change.
+type Event struct {
+	Actor      string
+	Action     string
+	EntityType string
+	EntityID   *int
+	Before     any
+	After      any
+}
+
+type Store interface {
+	CreateAuditEvent(ctx context.Context, event *structs.AuditEvent) error
+}
+
+type Recorder struct {
+	store Store
+	log   *slog.Logger
+}
+
+func NewRecorder(store Store, log *slog.Logger) *Recorder {
+	return &Recorder{store: store, log: log}
+}
+
+// Record appends the event to the audit log. The change it describes has
+// already been made, so a failure is logged rather than returned, and the
+// event is written even if the client has gone away.
+func (rec *Recorder) Record(ctx context.Context, event Event) {
+	stored := structs.AuditEvent{
+		Actor:      event.Actor,
+		Action:     event.Action,
+		EntityType: event.EntityType,
+		EntityID:   event.EntityID,
+		RequestID:  middleware.GetReqID(ctx),
+	}
+	if stored.Actor == "" {
+		stored.Actor = anonymousActor
+		if principal, ok := jwt.PrincipalFromContext(ctx); ok {
+			stored.Actor = principal.Username
+		}
+	}
+
+	var err error
+	if stored.Before, err = marshal(event.Before); err == nil {
+		stored.After, err = marshal(event.After)
+	}
+	if err == nil {
+		err = rec.store.CreateAuditEvent(context.WithoutCancel(ctx), &stored)
+	}
+	if err != nil {
+		rec.log.Error("failed to record audit event", errMsg.Err(err),
+			slog.String("action", event.Action),
+			slog.String("entity_type", event.EntityType),
+			slog.String("actor", stored.Actor))
+	}
+}
+
+// ID returns a pointer to id for Event.EntityID.
+func ID(id int) *int {
+	return &id
+}
+
+func marshal(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,115 @@
+package jwt
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type RevocationStore interface {
+	RevokeTokenID(ctx context.Context, jti string, expiresAt time.Time) error
+	FindRevokedTokenIDs(ctx context.Context) (map[string]time.Time, error)
+	FindRevokedUsernames(ctx context.Context) (map[string]time.Time, error)
+	DeleteExpiredTokens(ctx context.Context) error
+}
+
+// DenyList holds the ids of access tokens revoked before their expiry, and
+// the usernames whose tokens issued up to some moment are refused. It is
+// kept in memory so verifying a token does not hit the database, and is
+// reloaded from the store periodically to pick up revocations made by other
+// replicas. A revocation is refused at once on the replica that made it and
+// on the others after their next Sync, so within the sync interval.
+type DenyList struct {
+	store RevocationStore
+	log   *slog.Logger
+
+	mu    sync.RWMutex
+	ids   map[string]time.Time
+	users map[string]time.Time
+}
+
+func NewDenyList(store RevocationStore, log *slog.Logger) *DenyList {
+	return &DenyList{store: store, log: log, ids: make(map[string]time.Time), users: make(map[string]time.Time)}
+}
+
+// Revoke stores jti so the token is refused from now on. expiresAt is the
+// expiry of the token, after which the entry is dropped.
+func (d *DenyList) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := d.store.RevokeTokenID(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ids[jti] = expiresAt
+	return nil
+}
+
+func (d *DenyList) IsRevoked(jti string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.ids[jti]
+	return ok
+}
+
+// AddRevokedUser refuses the tokens of username issued up to revokedAt on
+// this replica. The revocation itself is stored by the caller, in the same
+// transaction as the change that caused it, and reaches the other replicas
+// with Sync.
+func (d *DenyList) AddRevokedUser(username string, revokedAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if revokedAt.After(d.users[username]) {
+		d.users[username] = revokedAt
+	}
+}
+
+// IsUserRevoked reports whether a token of username issued at issuedAt was
+// revoked. Token times have a precision of one second, so a token issued
+// in the same second as the revocation is refused too.
+func (d *DenyList) IsUserRevoked(username string, issuedAt time.Time) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	revokedAt, ok := d.users[username]
+	return ok && issuedAt.Unix() <= revokedAt.Unix()
+}
+
+// Sync prunes expired entries from the store and reloads the rest.
+func (d *DenyList) Sync(ctx context.Context) error {
+	if err := d.store.DeleteExpiredTokens(ctx); err != nil {
+		return err
+	}
+	ids, err := d.store.FindRevokedTokenIDs(ctx)
+	if err != nil {
+		return err
+	}
+	users, err := d.store.FindRevokedUsernames(ctx)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ids = ids
+	d.users = users
+	return nil
+}
+
+// Run calls Sync every interval until ctx is done.
+func (d *DenyList) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Sync(ctx); err != nil && ctx.Err() == nil {
+				d.log.Error("failed to sync token deny-list", errMsg.Err(err))
+			}
+		}
+	}
+}
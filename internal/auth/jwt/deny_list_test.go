@@ -0,0 +1,46 @@
+package jwt
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestDenyListIsUserRevoked(t *testing.T) {
+	revokedAt := time.Date(2024, 5, 1, 12, 0, 0, 500_000_000, time.UTC)
+	denyList := NewDenyList(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	denyList.AddRevokedUser("alice", revokedAt)
+
+	tests := []struct {
+		name     string
+		username string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "issued before", username: "alice", issuedAt: revokedAt.Add(-time.Hour), want: true},
+		{name: "issued in the same second", username: "alice", issuedAt: revokedAt.Add(400 * time.Millisecond), want: true},
+		{name: "issued a second later", username: "alice", issuedAt: revokedAt.Add(time.Second), want: false},
+		{name: "other user", username: "bob", issuedAt: revokedAt.Add(-time.Hour), want: false},
+		{name: "no iat", username: "alice", issuedAt: time.Unix(0, 0), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := denyList.IsUserRevoked(tt.username, tt.issuedAt); got != tt.want {
+				t.Errorf("IsUserRevoked(%q, %v) = %v, want %v", tt.username, tt.issuedAt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDenyListAddRevokedUserKeepsLatest(t *testing.T) {
+	later := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	denyList := NewDenyList(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	denyList.AddRevokedUser("alice", later)
+	denyList.AddRevokedUser("alice", later.Add(-time.Hour))
+
+	if !denyList.IsUserRevoked("alice", later.Add(-time.Minute)) {
+		t.Error("an earlier revocation replaced a later one")
+	}
+}
@@ -2,6 +2,7 @@ package jwt
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -16,24 +17,49 @@ type Claims struct {
 	jwt.StandardClaims
 }
 
+var ErrTokenRevoked = errors.New("token revoked")
+
 type JWTManager struct {
-	secret []byte
-	log    *slog.Logger
+	keys     *KeySet
+	denyList *DenyList
+	log      *slog.Logger
+}
+
+// NewJWTManager returns a manager signing tokens with the signing key of keys
+// and accepting tokens signed by any key of the set. Tokens whose id is on
+// denyList are refused; denyList may be nil.
+func NewJWTManager(keys *KeySet, denyList *DenyList, log *slog.Logger) *JWTManager {
+	return &JWTManager{keys: keys, denyList: denyList, log: log}
 }
 
-func NewJWTManager(secret string, log *slog.Logger) *JWTManager {
-	return &JWTManager{secret: []byte(secret), log: log}
+// JWKS returns the public keys other services can verify our tokens with.
+func (manager *JWTManager) JWKS() JWKS {
+	return manager.keys.JWKS()
 }
 
+// GenerateToken issues an access token. Every token gets a random jti claim
+// so it can be revoked on its own, and an iat claim so every token of a user
+// can be revoked at once.
 func (manager *JWTManager) GenerateToken(username, role string, expiration time.Duration) (string, error) {
+	jti, err := randomString(16)
+	if err != nil {
+		manager.log.Error("Failed to generate token id", errMsg.Err(err))
+		return "", err
+	}
+
+	now := time.Now()
 	claims := jwt.MapClaims{
-		"usename": username,
-		"role":    role,
-		"exp":     time.Now().Add(expiration).Unix(),
+		"username": username,
+		"role":     role,
+		"jti":      jti,
+		"iat":      now.Unix(),
+		"exp":      now.Add(expiration).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString(manager.secret)
+	key := manager.keys.signing
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID
+	signedToken, err := token.SignedString(key.signKey)
 	if err != nil {
 		manager.log.Error("Failed to sign token")
 		return "", fmt.Errorf("failed to sign token: %w", err)
@@ -43,13 +69,7 @@ func (manager *JWTManager) GenerateToken(username, role string, expiration time.
 }
 
 func (manager *JWTManager) VerifyToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			manager.log.Error("Unexpected signing method")
-			return nil, errors.New("unexpected signing method")
-		}
-		return manager.secret, nil
-	})
+	token, err := jwt.Parse(tokenString, manager.verificationKey)
 	if err != nil {
 		manager.log.Error("failed to parse token", errMsg.Err(err))
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -61,9 +81,50 @@ func (manager *JWTManager) VerifyToken(tokenString string) (jwt.MapClaims, error
 		return nil, errors.New("invalid token")
 	}
 
+	if jti, _ := claims["jti"].(string); jti != "" && manager.denyList != nil && manager.denyList.IsRevoked(jti) {
+		manager.log.Info("revoked token presented")
+		return nil, ErrTokenRevoked
+	}
+
+	// Tokens issued before iat was introduced count as issued at the epoch.
+	username, _ := claims["username"].(string)
+	issuedAt, _ := claims["iat"].(float64)
+	if manager.denyList != nil && manager.denyList.IsUserRevoked(username, time.Unix(int64(issuedAt), 0)) {
+		manager.log.Info("token of a revoked user presented")
+		return nil, ErrTokenRevoked
+	}
+
 	return claims, nil
 }
 
+// RevokeToken puts the access token on the deny-list until it expires.
+func (manager *JWTManager) RevokeToken(ctx context.Context, tokenString string) error {
+	if manager.denyList == nil {
+		return errors.New("token revocation is not configured")
+	}
+
+	claims, err := manager.VerifyToken(tokenString)
+	if err != nil {
+		return err
+	}
+
+	jti, _ := claims["jti"].(string)
+	exp, ok := claims["exp"].(float64)
+	if jti == "" || !ok {
+		return errors.New("token cannot be revoked")
+	}
+	return manager.denyList.Revoke(ctx, jti, time.Unix(int64(exp), 0))
+}
+
+// ApplyUserRevocation refuses the access tokens issued to username up to
+// revokedAt on this replica right away, once the revocation is stored.
+// Other replicas refuse them after their next deny-list sync.
+func (manager *JWTManager) ApplyUserRevocation(username string, revokedAt time.Time) {
+	if manager.denyList != nil {
+		manager.denyList.AddRevokedUser(username, revokedAt)
+	}
+}
+
 func (manager *JWTManager) ExtractRoleFromToken(tokenString string) (string, error) {
 	claims, err := manager.VerifyToken(tokenString)
 	if err != nil {
@@ -81,25 +142,29 @@ func (manager *JWTManager) ExtractRoleFromToken(tokenString string) (string, err
 }
 
 func (manager *JWTManager) ExtractRoleAndUsernameFromToken(tokenString string) (string, string, error) {
-	claims := &Claims{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			manager.log.Error("Unexpected signing method")
-			return nil, errors.New("unexpected signing method")
-		}
-		return manager.secret, nil
-	})
-
+	claims, err := manager.VerifyToken(tokenString)
 	if err != nil {
-		manager.log.Error("Failed to parse token", errMsg.Err(err))
-		return "", "", fmt.Errorf("failed to parse token: %w", err)
+		return "", "", err
 	}
 
-	if !token.Valid {
-		manager.log.Error("Invalid token")
-		return "", "", errors.New("invalid token")
-	}
+	role, _ := claims["role"].(string)
+	username, _ := claims["username"].(string)
+	return role, username, nil
+}
 
-	return claims.Username, claims.Role, nil
+// verificationKey picks the key named in the kid header and refuses tokens
+// whose algorithm differs from the one of that key, so an RS256 public key
+// can never be used as an HS256 secret.
+func (manager *JWTManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := manager.keys.Lookup(kid)
+	if !ok {
+		manager.log.Error("Unknown signing key", slog.String("kid", kid))
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		manager.log.Error("Unexpected signing method")
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifyKey, nil
 }
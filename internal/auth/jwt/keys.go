@@ -0,0 +1,159 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// Key is one entry of a KeySet. Keys without a private part can only verify
+// tokens, which is how retired signing keys are kept around until the tokens
+// they issued expire.
+type Key struct {
+	ID        string
+	Algorithm string
+
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+func NewHMACKey(id string, secret []byte) (*Key, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("key %q: empty secret", id)
+	}
+	return &Key{ID: id, Algorithm: AlgHS256, method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+}
+
+// ParsePEMKey builds an RS256 or ES256 key from a PEM encoded private or
+// public key. A public key gives a verification-only key.
+func ParsePEMKey(id, algorithm string, pemBytes []byte) (*Key, error) {
+	key := &Key{ID: id, Algorithm: algorithm}
+
+	switch algorithm {
+	case AlgRS256:
+		key.method = jwt.SigningMethodRS256
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes); err == nil {
+			key.signKey, key.verifyKey = private, &private.PublicKey
+		} else if public, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes); err == nil {
+			key.verifyKey = public
+		} else {
+			return nil, fmt.Errorf("key %q: not an RSA key in PEM format", id)
+		}
+	case AlgES256:
+		key.method = jwt.SigningMethodES256
+		var public *ecdsa.PublicKey
+		if private, err := jwt.ParseECPrivateKeyFromPEM(pemBytes); err == nil {
+			key.signKey, public = private, &private.PublicKey
+		} else if public, err = jwt.ParseECPublicKeyFromPEM(pemBytes); err != nil {
+			return nil, fmt.Errorf("key %q: not an EC key in PEM format", id)
+		}
+		if public.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("key %q: ES256 needs a P-256 key", id)
+		}
+		key.verifyKey = public
+	default:
+		return nil, fmt.Errorf("key %q: unsupported algorithm %q", id, algorithm)
+	}
+
+	return key, nil
+}
+
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// KeySet holds the key new tokens are signed with and every key tokens are
+// still accepted from. Tokens name their key in the kid header.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+func NewKeySet(signingKeyID string, keys ...*Key) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("key without an id")
+		}
+		if _, ok := set.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		set.keys[key.ID] = key
+	}
+
+	signing, ok := set.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q is not in the key set", signingKeyID)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("signing key %q has no private key", signingKeyID)
+	}
+	set.signing = signing
+	return set, nil
+}
+
+// Lookup returns the key named by kid. Tokens issued before kid was
+// introduced have none and are checked against the signing key.
+func (s *KeySet) Lookup(kid string) (*Key, bool) {
+	if kid == "" {
+		return s.signing, true
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set. HMAC keys are shared secrets and
+// are never published.
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		jwk := JWK{Use: "sig", KeyID: key.ID, Algorithm: key.Algorithm}
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			jwk.KeyType = "EC"
+			jwk.Curve = "P-256"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, 32)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, 32)))
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID })
+	return jwks
+}
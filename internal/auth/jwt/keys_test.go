@@ -0,0 +1,81 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestVerifyTokenRefusesAlgorithmConfusion(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+	publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	rsaKey, err := ParsePEMKey("rsa", AlgRS256, privatePEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacKey, err := NewHMACKey("hmac", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewKeySet("rsa", rsaKey, hmacKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := NewJWTManager(keys, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{
+			"username": "alice",
+			"role":     "user",
+			"exp":      time.Now().Add(time.Hour).Unix(),
+		})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "RS256 with its key", token: sign(jwt.SigningMethodRS256, "rsa", private)},
+		{name: "RS256 without kid uses the signing key", token: sign(jwt.SigningMethodRS256, "", private)},
+		{name: "HS256 with its key", token: sign(jwt.SigningMethodHS256, "hmac", []byte("secret"))},
+		{name: "HS256 signed with the RSA public key", token: sign(jwt.SigningMethodHS256, "rsa", publicPEM), wantErr: true},
+		{name: "HS256 signed with the RSA public key, no kid", token: sign(jwt.SigningMethodHS256, "", publicPEM), wantErr: true},
+		{name: "RS256 naming the HMAC key", token: sign(jwt.SigningMethodRS256, "hmac", private), wantErr: true},
+		{name: "unsigned", token: sign(jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType), wantErr: true},
+		{name: "unknown kid", token: sign(jwt.SigningMethodRS256, "other", private), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := manager.VerifyToken(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package jwt
+
+import "banner-serivce/internal/auth"
+
+type Permission string
+
+const (
+	PermReadUserBanner Permission = "user_banner:read"
+	PermManageBanners  Permission = "banners:manage"
+	PermManageTags     Permission = "tags:manage"
+	PermManageFeatures Permission = "features:manage"
+	PermReadAudit      Permission = "audit:read"
+	PermManageUsers    Permission = "users:manage"
+)
+
+var rolePermissions = map[string][]Permission{
+	auth.RoleAdmin: {PermReadUserBanner, PermManageBanners, PermManageTags, PermManageFeatures, PermReadAudit, PermManageUsers},
+	auth.RoleUser:  {PermReadUserBanner},
+}
+
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
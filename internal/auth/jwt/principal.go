@@ -0,0 +1,34 @@
+package jwt
+
+import (
+	"context"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Username string
+	Role     string
+	TokenID  string
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller stored by the auth middleware. ok is
+// false for requests that did not pass through it.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+func principalFromClaims(claims jwt.MapClaims) Principal {
+	username, _ := claims["username"].(string)
+	role, _ := claims["role"].(string)
+	tokenID, _ := claims["jti"].(string)
+	return Principal{Username: username, Role: role, TokenID: tokenID}
+}
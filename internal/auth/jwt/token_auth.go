@@ -2,60 +2,72 @@ package jwt
 
 import (
 	"banner-serivce/internal/api/response"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/go-chi/render"
 	"net/http"
 	"strings"
 )
 
+// TokenAuthMiddleware authenticates the request and stores the caller in its
+// context, see PrincipalFromContext.
 func TokenAuthMiddleware(jwtManager *JWTManager, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenString := r.Header.Get("Authorization")
-		if tokenString == "" {
-			render.JSON(w, r, response.Error("unauthorized"))
+		claims, ok := verifyRequest(jwtManager, w, r)
+		if !ok {
 			return
 		}
-
-		token := strings.Split(tokenString, " ")
-		if len(token) != 2 || token[0] != "Bearer" {
-			render.JSON(w, r, response.Error("unauthorized"))
-			return
-		}
-
-		_, err := jwtManager.VerifyToken(token[1])
-		if err != nil {
-			render.JSON(w, r, response.Error("unauthorized"))
-			return
-		}
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principalFromClaims(claims))))
 	})
 }
 
-func TokenAuthAndRoleMiddleware(jwtManager *JWTManager, next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenString := r.Header.Get("Authorization")
-		if tokenString == "" {
-			render.JSON(w, r, response.Error("unauthorized"))
-			return
-		}
+// RequirePermission authenticates the request and lets it through only when
+// the role from the token grants perm. The caller is stored in the request
+// context like TokenAuthMiddleware does.
+func RequirePermission(jwtManager *JWTManager, perm Permission) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := verifyRequest(jwtManager, w, r)
+			if !ok {
+				return
+			}
 
-		token := strings.Split(tokenString, " ")
-		if len(token) != 2 || token[0] != "Bearer" {
-			render.JSON(w, r, response.Error("unauthorized"))
-			return
-		}
+			principal := principalFromClaims(claims)
+			if !HasPermission(principal.Role, perm) {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("forbidden"))
+				return
+			}
 
-		claims, err := jwtManager.VerifyToken(token[1])
-		if err != nil {
-			render.JSON(w, r, response.Error("Unauthorized"))
-			return
-		}
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
 
-		role, ok := claims["role"].(string)
-		if !ok || role != "admin" {
-			render.JSON(w, r, response.Error("forbidden"))
-			return
-		}
+// BearerToken returns the token from the Authorization header of the request.
+func BearerToken(r *http.Request) (string, bool) {
+	token := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(token) != 2 || token[0] != "Bearer" || token[1] == "" {
+		return "", false
+	}
+	return token[1], true
+}
 
-		next.ServeHTTP(w, r)
-	})
+func verifyRequest(jwtManager *JWTManager, w http.ResponseWriter, r *http.Request) (jwt.MapClaims, bool) {
+	token, ok := BearerToken(r)
+	if !ok {
+		unauthorized(w, r)
+		return nil, false
+	}
+
+	claims, err := jwtManager.VerifyToken(token)
+	if err != nil {
+		unauthorized(w, r)
+		return nil, false
+	}
+	return claims, true
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusUnauthorized)
+	render.JSON(w, r, response.Error("unauthorized"))
 }
@@ -0,0 +1,243 @@
+package banners
+
+import (
+	"banner-serivce/internal/audit"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"context"
+	"errors"
+	"time"
+)
+
+// maxUpdateAttempts bounds how often a change is retried when the banner is
+// modified concurrently.
+const maxUpdateAttempts = 3
+
+// Repository is the part of the banner repository the editor works with.
+type Repository interface {
+	FindBannerIDs(ctx context.Context, featureID, tagID *int) ([]int, error)
+	FindBannerByID(ctx context.Context, id int) (structs.Banner, error)
+	DeleteBannerByID(ctx context.Context, id int, ifVersions []int) error
+	UpdateBanner(ctx context.Context, banner *structs.Banner, ifVersions []int) error
+	ActivateBannerRevision(ctx context.Context, bannerID, version int, ifVersions []int) (structs.Banner, error)
+	ReplaceBannerVariants(ctx context.Context, bannerID int, variants []structs.BannerVariant) ([]structs.BannerVariant, error)
+}
+
+// Cache drops banners that were changed, see cache.BannerCache.
+type Cache interface {
+	Invalidate(banner structs.Banner)
+}
+
+// Auditor records administrative changes, see audit.Recorder.
+type Auditor interface {
+	Record(ctx context.Context, event audit.Event)
+}
+
+// Editor makes banner changes that several handlers share. Every change goes
+// through the banner repository one banner at a time, so each banner is
+// versioned, audited and dropped from the cache the same way as when it is
+// changed on its own.
+type Editor struct {
+	repo    Repository
+	cache   Cache
+	auditor Auditor
+}
+
+func NewEditor(repo Repository, cache Cache, auditor Auditor) *Editor {
+	return &Editor{repo: repo, cache: cache, auditor: auditor}
+}
+
+// Delete deletes the banner and records action in the audit log. When
+// ifVersions is not nil the banner is only deleted if its current version is
+// one of them. It returns the banner as it was before.
+func (e *Editor) Delete(ctx context.Context, id int, ifVersions []int, action string) (structs.Banner, error) {
+	banner, err := e.repo.FindBannerByID(ctx, id)
+	if err != nil {
+		return structs.Banner{}, err
+	}
+	if err := e.repo.DeleteBannerByID(ctx, id, ifVersions); err != nil {
+		return structs.Banner{}, err
+	}
+
+	e.cache.Invalidate(banner)
+	e.auditor.Record(ctx, audit.Event{
+		Action:     action,
+		EntityType: audit.EntityBanner,
+		EntityID:   audit.ID(id),
+		Before:     banner,
+	})
+	return banner, nil
+}
+
+// Update writes banner, which was read as before, expecting the version it
+// was read at, and records the change in the audit log.
+func (e *Editor) Update(ctx context.Context, before structs.Banner, banner *structs.Banner) error {
+	if err := e.repo.UpdateBanner(ctx, banner, []int{before.Version}); err != nil {
+		return err
+	}
+
+	e.changed(ctx, audit.ActionUpdate, before, *banner)
+	return nil
+}
+
+// ReplaceVariants replaces the A/B variants of the banner and records the
+// change in the audit log. It returns the stored variants.
+func (e *Editor) ReplaceVariants(ctx context.Context, id int, variants []structs.BannerVariant) ([]structs.BannerVariant, error) {
+	banner, err := e.repo.FindBannerByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	variants, err = e.repo.ReplaceBannerVariants(ctx, id, variants)
+	if err != nil {
+		return nil, err
+	}
+
+	e.cache.Invalidate(banner)
+	e.auditor.Record(ctx, audit.Event{
+		Action:     audit.ActionReplaceVariants,
+		EntityType: audit.EntityBanner,
+		EntityID:   audit.ID(id),
+		Before:     banner.Variants,
+		After:      variants,
+	})
+	return variants, nil
+}
+
+// Activate restores the banner to the given revision and records action in
+// the audit log. When ifVersions is not nil the banner is only restored if
+// its current version is one of them. It returns the restored banner.
+func (e *Editor) Activate(ctx context.Context, id, version int, ifVersions []int) (structs.Banner, error) {
+	before, err := e.repo.FindBannerByID(ctx, id)
+	if err != nil {
+		return structs.Banner{}, err
+	}
+	banner, err := e.repo.ActivateBannerRevision(ctx, id, version, ifVersions)
+	if err != nil {
+		return structs.Banner{}, err
+	}
+
+	e.changed(ctx, audit.ActionActivateVersion, before, banner)
+	return banner, nil
+}
+
+// DeleteMatching deletes every banner of the feature and/or tag with Delete
+// and returns how many were deleted. Banners deleted concurrently are
+// skipped. progress may be nil.
+func (e *Editor) DeleteMatching(ctx context.Context, featureID, tagID *int, action string, progress func(total, done int)) (int, error) {
+	if progress == nil {
+		progress = func(total, done int) {}
+	}
+
+	ids, err := e.repo.FindBannerIDs(ctx, featureID, tagID)
+	if err != nil {
+		return 0, err
+	}
+	progress(len(ids), 0)
+
+	deleted := 0
+	for i, id := range ids {
+		_, err := e.Delete(ctx, id, nil, action)
+		if err != nil && !errors.Is(err, storage.ErrBannerNotFound) {
+			return deleted, err
+		}
+		if err == nil {
+			deleted++
+		}
+		progress(len(ids), i+1)
+	}
+	return deleted, nil
+}
+
+// RemoveTag takes the tag off every banner that has it, through the regular
+// banner update, so each banner gets a new version and revision, an audit
+// event and leaves the cache. A banner whose only tag it is would become
+// unreachable, so when there is one nothing is changed and a
+// *storage.LastTagError lists them.
+func (e *Editor) RemoveTag(ctx context.Context, tagID int) error {
+	ids, err := e.repo.FindBannerIDs(ctx, nil, &tagID)
+	if err != nil {
+		return err
+	}
+
+	tagged := make([]structs.Banner, 0, len(ids))
+	var lastTag []int
+	for _, id := range ids {
+		banner, err := e.repo.FindBannerByID(ctx, id)
+		if errors.Is(err, storage.ErrBannerNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(banner.TagIDs) == 1 {
+			lastTag = append(lastTag, id)
+		}
+		tagged = append(tagged, banner)
+	}
+	if len(lastTag) > 0 {
+		return &storage.LastTagError{TagID: tagID, BannerIDs: lastTag}
+	}
+
+	for _, banner := range tagged {
+		if err := e.removeTag(ctx, banner, tagID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeTag updates banner without tagID, expecting the version it was read
+// at and reading it again when it has changed since.
+func (e *Editor) removeTag(ctx context.Context, banner structs.Banner, tagID int) error {
+	for attempt := 1; ; attempt++ {
+		updated := banner
+		updated.TagIDs = withoutTag(banner.TagIDs, tagID)
+		if len(updated.TagIDs) == len(banner.TagIDs) {
+			return nil
+		}
+		if len(updated.TagIDs) == 0 {
+			return &storage.LastTagError{TagID: tagID, BannerIDs: []int{banner.ID}}
+		}
+		updated.UpdatedAt = time.Now()
+
+		err := e.Update(ctx, banner, &updated)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, storage.ErrVersionMismatch) || attempt == maxUpdateAttempts {
+			return err
+		}
+
+		banner, err = e.repo.FindBannerByID(ctx, banner.ID)
+		if errors.Is(err, storage.ErrBannerNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// changed drops the banner from the cache under the feature and tag pairs it
+// had before and has now, and records the change in the audit log.
+func (e *Editor) changed(ctx context.Context, action string, before, after structs.Banner) {
+	e.cache.Invalidate(before)
+	e.cache.Invalidate(after)
+	e.auditor.Record(ctx, audit.Event{
+		Action:     action,
+		EntityType: audit.EntityBanner,
+		EntityID:   audit.ID(before.ID),
+		Before:     before,
+		After:      after,
+	})
+}
+
+func withoutTag(tagIDs []int, tagID int) []int {
+	kept := make([]int, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		if id != tagID {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"banner-serivce/internal/structs"
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type BannerSource interface {
+	FindBannerByFeatureTag(ctx context.Context, featureID, tagID int) (*structs.Banner, error)
+	FindBannersByFeatureTags(ctx context.Context, pairs []structs.FeatureTag) (map[structs.FeatureTag]structs.Banner, error)
+}
+
+type key struct {
+	featureID int
+	tagID     int
+}
+
+type entry struct {
+	key      key
+	banner   structs.Banner
+	storedAt time.Time
+}
+
+// BannerCache keeps the most recently used banners in memory so that
+// /user_banner does not hit Postgres on every request. Entries older than
+// ttl are refetched, and the least recently used entry is evicted once
+// maxEntries is reached.
+type BannerCache struct {
+	source     BannerSource
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[key]*list.Element
+	order   *list.List
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func NewBannerCache(source BannerSource, ttl time.Duration, maxEntries int) *BannerCache {
+	return &BannerCache{
+		source:     source,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[key]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// GetBanner returns the banner for the feature and tag pair. When
+// useLastRevision is set the cache is bypassed and the fresh row replaces
+// whatever was cached.
+func (c *BannerCache) GetBanner(ctx context.Context, featureID, tagID int, useLastRevision bool) (*structs.Banner, error) {
+	k := key{featureID: featureID, tagID: tagID}
+
+	if !useLastRevision {
+		if banner, ok := c.get(k); ok {
+			c.hits.Add(1)
+			return &banner, nil
+		}
+	}
+	c.misses.Add(1)
+
+	banner, err := c.source.FindBannerByFeatureTag(ctx, featureID, tagID)
+	if err != nil {
+		return nil, err
+	}
+	c.put(k, *banner)
+	return banner, nil
+}
+
+// GetBanners returns the banners for several feature and tag pairs. Pairs
+// missing from the cache are fetched from the source with a single call;
+// pairs without a banner are left out of the result.
+func (c *BannerCache) GetBanners(ctx context.Context, pairs []structs.FeatureTag, useLastRevision bool) (map[structs.FeatureTag]structs.Banner, error) {
+	banners := make(map[structs.FeatureTag]structs.Banner, len(pairs))
+	var missing []structs.FeatureTag
+
+	for _, pair := range pairs {
+		if !useLastRevision {
+			if banner, ok := c.get(key{featureID: pair.FeatureID, tagID: pair.TagID}); ok {
+				c.hits.Add(1)
+				banners[pair] = banner
+				continue
+			}
+		}
+		c.misses.Add(1)
+		missing = append(missing, pair)
+	}
+	if len(missing) == 0 {
+		return banners, nil
+	}
+
+	fetched, err := c.source.FindBannersByFeatureTags(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for pair, banner := range fetched {
+		c.put(key{featureID: pair.FeatureID, tagID: pair.TagID}, banner)
+		banners[pair] = banner
+	}
+	return banners, nil
+}
+
+// Invalidate drops the cached entries of every feature and tag pair of the
+// banner. Only this process is affected; other replicas refetch the banner
+// once their entry is older than ttl.
+func (c *BannerCache) Invalidate(banner structs.Banner) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tagID := range banner.TagIDs {
+		k := key{featureID: banner.FeatureID, tagID: tagID}
+		if el, ok := c.entries[k]; ok {
+			c.order.Remove(el)
+			delete(c.entries, k)
+		}
+	}
+}
+
+func (c *BannerCache) get(k key) (structs.Banner, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[k]
+	if !ok {
+		return structs.Banner{}, false
+	}
+	e := el.Value.(*entry)
+	if time.Since(e.storedAt) > c.ttl {
+		c.order.Remove(el)
+		delete(c.entries, k)
+		return structs.Banner{}, false
+	}
+	c.order.MoveToFront(el)
+	return e.banner, true
+}
+
+func (c *BannerCache) put(k key, banner structs.Banner) {
+	if c.maxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[k]; ok {
+		e := el.Value.(*entry)
+		e.banner = banner
+		e.storedAt = time.Now()
+		c.order.MoveToFront(el)
+		return
+	}
+
+	for c.order.Len() >= c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+	c.entries[k] = c.order.PushFront(&entry{key: k, banner: banner, storedAt: time.Now()})
+}
+
+func (c *BannerCache) Hits() uint64 {
+	return c.hits.Load()
+}
+
+func (c *BannerCache) Misses() uint64 {
+	return c.misses.Load()
+}
+
+func (c *BannerCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Ping reports whether the cache can serve requests. The cache lives in
+// process memory, so it is always available.
+func (c *BannerCache) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
@@ -9,11 +9,16 @@ import (
 )
 
 type Config struct {
-	Env              string         `yaml:"env" env-default:"local"`
-	HTTPServer       ServerCfg      `yaml:"http_server"`
-	Database         DatabaseConfig `yaml:"database"`
-	JWT              JWTCfg         `yaml:"auth"`
-	DefaultAdminPass string         `yaml:"default_admin_pass"`
+	Env          string         `yaml:"env" env-default:"local"`
+	HTTPServer   ServerCfg      `yaml:"http_server"`
+	Database     DatabaseConfig `yaml:"database"`
+	JWT          JWTCfg         `yaml:"jwt"`
+	Cache        CacheCfg       `yaml:"cache"`
+	UserBanner   UserBannerCfg  `yaml:"user_banner"`
+	Revisions    RevisionsCfg   `yaml:"revisions"`
+	Jobs         JobsCfg        `yaml:"jobs"`
+	Health       HealthCfg      `yaml:"health"`
+	DefaultAdmin AdminCfg       `yaml:"default_admin"`
 }
 
 type DatabaseConfig struct {
@@ -28,10 +33,75 @@ type ServerCfg struct {
 	Addr        string        `yaml:"address" env-default:"localhost:8080"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"10s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"120s"`
+	// ShutdownTimeout bounds how long in-flight requests and background
+	// jobs may run after a termination signal.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"15s"`
+}
+
+type CacheCfg struct {
+	TTL        time.Duration `yaml:"ttl" env-default:"5m"`
+	MaxEntries int           `yaml:"max_entries" env-default:"10000"`
+}
+
+type UserBannerCfg struct {
+	// CacheControl is sent with every /user_banner response. Keep it private,
+	// since the response depends on the caller's role.
+	CacheControl string `yaml:"cache_control" env-default:"private, max-age=60"`
+}
+
+type RevisionsCfg struct {
+	Keep int `yaml:"keep" env-default:"10"`
+}
+
+type JobsCfg struct {
+	Workers int `yaml:"workers" env-default:"2"`
+	// PollInterval is how often idle workers look for jobs queued by other
+	// replicas. Jobs queued by this replica are picked up at once.
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"1s"`
+	// Lease is how long a running job may go without a heartbeat before
+	// another worker takes it over.
+	Lease     time.Duration `yaml:"lease" env-default:"30s"`
+	Retention time.Duration `yaml:"retention" env-default:"1h"`
+}
+
+type HealthCfg struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env-default:"2s"`
+	// DrainDelay is how long /readyz reports not-ready before the server
+	// stops accepting connections.
+	DrainDelay time.Duration `yaml:"drain_delay" env-default:"5s"`
+}
+
+// AdminCfg is the account created on first start when no admin exists yet.
+type AdminCfg struct {
+	Username string `yaml:"username" env:"DEFAULT_ADMIN_USERNAME" env-default:"admin"`
+	Password string `yaml:"password" env:"DEFAULT_ADMIN_PASSWORD"`
 }
 
 type JWTCfg struct {
+	// Secret is the HS256 signing key used when Keys is empty.
 	Secret string `yaml:"secret"`
+	// Keys lists every key tokens are accepted from; SigningKey is the kid of
+	// the one new tokens are signed with. To rotate, add the new key, make it
+	// the signing key and drop the old one once its tokens have expired.
+	Keys       []JWTKeyCfg   `yaml:"keys"`
+	SigningKey string        `yaml:"signing_key"`
+	AccessTTL  time.Duration `yaml:"access_ttl" env-default:"10m"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl" env-default:"720h"`
+	// DenyListSync is how often revoked access tokens are reloaded from the
+	// database. A token revoked on one replica, including the tokens of a
+	// user who is disabled, deleted or changes role, is accepted by the
+	// other replicas for at most this long.
+	DenyListSync time.Duration `yaml:"deny_list_sync" env-default:"30s"`
+}
+
+// JWTKeyCfg is one signing or verification key. HS256 keys take Secret;
+// RS256 and ES256 keys take a PEM file with a private key, or with a public
+// key for a verification-only key.
+type JWTKeyCfg struct {
+	ID        string `yaml:"kid"`
+	Algorithm string `yaml:"alg"`
+	Secret    string `yaml:"secret"`
+	KeyFile   string `yaml:"key_file"`
 }
 
 func MustLoad() *Config {
@@ -0,0 +1,96 @@
+package crud
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	audithandlers "banner-serivce/internal/handlers/audit_handlers"
+	"banner-serivce/internal/metrics"
+	"banner-serivce/internal/structs"
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func NewAuditRepository(db *pgxpool.Pool, log *slog.Logger) *AuditRepository {
+	return &AuditRepository{db, log}
+}
+
+func (ar *AuditRepository) CreateAuditEvent(ctx context.Context, event *structs.AuditEvent) error {
+	defer metrics.ObserveRepository("audit", "CreateAuditEvent", time.Now())
+
+	err := ar.db.QueryRow(ctx,
+		`INSERT INTO audit_events (actor, action, entity_type, entity_id, before, after, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		RETURNING id, occurred_at`,
+		event.Actor, event.Action, event.EntityType, event.EntityID, event.Before, event.After, event.RequestID).
+		Scan(&event.ID, &event.OccurredAt)
+	if err != nil {
+		ar.log.Error("Failed to create audit event", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+func (ar *AuditRepository) FindAuditEvents(ctx context.Context, params audithandlers.RequestGetAuditEvents) ([]structs.AuditEvent, error) {
+	defer metrics.ObserveRepository("audit", "FindAuditEvents", time.Now())
+
+	query := `SELECT id, occurred_at, actor, action, entity_type, entity_id, before, after, COALESCE(request_id, '')
+	FROM audit_events WHERE 1=1`
+	args := []interface{}{}
+
+	if params.EntityType != "" {
+		args = append(args, params.EntityType)
+		query += " AND entity_type = $" + strconv.Itoa(len(args))
+	}
+	if params.EntityID != nil {
+		args = append(args, *params.EntityID)
+		query += " AND entity_id = $" + strconv.Itoa(len(args))
+	}
+	if params.Actor != "" {
+		args = append(args, params.Actor)
+		query += " AND actor = $" + strconv.Itoa(len(args))
+	}
+	if params.From != nil {
+		args = append(args, *params.From)
+		query += " AND occurred_at >= $" + strconv.Itoa(len(args))
+	}
+	if params.To != nil {
+		args = append(args, *params.To)
+		query += " AND occurred_at < $" + strconv.Itoa(len(args))
+	}
+
+	query += " ORDER BY occurred_at DESC, id DESC LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+	args = append(args, params.Limit, params.Offset)
+
+	rows, err := ar.db.Query(ctx, query, args...)
+	if err != nil {
+		ar.log.Error("Failed to query audit events", errMsg.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []structs.AuditEvent{}
+	for rows.Next() {
+		var event structs.AuditEvent
+		if err := rows.Scan(&event.ID, &event.OccurredAt, &event.Actor, &event.Action, &event.EntityType, &event.EntityID, &event.Before, &event.After, &event.RequestID); err != nil {
+			ar.log.Error("Failed to scan audit event", errMsg.Err(err))
+			return nil, err
+		}
+		event.OccurredAt = event.OccurredAt.UTC()
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		ar.log.Error("Error occurred while iterating audit events", errMsg.Err(err))
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,130 @@
+package crud
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/metrics"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (br *BannerRepository) FindBannerVariants(ctx context.Context, bannerID int) ([]structs.BannerVariant, error) {
+	defer metrics.ObserveRepository("banner", "FindBannerVariants", time.Now())
+
+	var exists bool
+	err := br.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM banners WHERE id = $1)`, bannerID).Scan(&exists)
+	if err != nil {
+		br.log.Error("Failed to check banner", errMsg.Err(err))
+		return nil, err
+	}
+	if !exists {
+		return nil, storage.ErrBannerNotFound
+	}
+
+	rows, err := br.db.Query(ctx,
+		`SELECT id, banner_id, content, weight FROM banner_variants WHERE banner_id = $1 ORDER BY id`, bannerID)
+	if err != nil {
+		br.log.Error("Failed to query banner variants", errMsg.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []structs.BannerVariant{}
+	for rows.Next() {
+		var variant structs.BannerVariant
+		if err := rows.Scan(&variant.ID, &variant.BannerID, &variant.Content, &variant.Weight); err != nil {
+			br.log.Error("Failed to scan banner variant", errMsg.Err(err))
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		br.log.Error("Error occurred while iterating banner variants", errMsg.Err(err))
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+// ReplaceBannerVariants swaps the whole set of variants of the banner. The new
+// variants get new ids, so clients caching a variant by its ETag refetch it.
+// An empty set turns the experiment off and the banner content is served
+// again. The banner gets a new version and revision, so its ETag changes and
+// activating an older revision restores the variants it had.
+func (br *BannerRepository) ReplaceBannerVariants(ctx context.Context, bannerID int, variants []structs.BannerVariant) ([]structs.BannerVariant, error) {
+	defer metrics.ObserveRepository("banner", "ReplaceBannerVariants", time.Now())
+
+	tx, err := br.db.Begin(ctx)
+	if err != nil {
+		br.log.Error("Failed to begin transaction", errMsg.Err(err))
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	banner := structs.Banner{ID: bannerID, UpdatedAt: time.Now()}
+	err = tx.QueryRow(ctx,
+		`UPDATE banners SET version = version + 1, updated_at = $2
+		WHERE id = $1
+		RETURNING feature_id, content, is_active, active_from, active_until, version`, bannerID, banner.UpdatedAt).
+		Scan(&banner.FeatureID, &banner.Content, &banner.IsActive, &banner.ActiveFrom, &banner.ActiveUntil, &banner.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrBannerNotFound
+		}
+		br.log.Error("Failed to bump banner version", errMsg.Err(err))
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `SELECT tag_id FROM banner_tags WHERE banner_id = $1 ORDER BY tag_id`, bannerID)
+	if err != nil {
+		br.log.Error("Failed to query banner tags", errMsg.Err(err))
+		return nil, err
+	}
+	banner.TagIDs, err = pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		br.log.Error("Failed to scan banner tags", errMsg.Err(err))
+		return nil, err
+	}
+
+	saved, err := br.replaceBannerVariants(ctx, tx, bannerID, variants)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := br.saveRevision(ctx, tx, &banner); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		br.log.Error("Failed to commit transaction", errMsg.Err(err))
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+func (br *BannerRepository) replaceBannerVariants(ctx context.Context, tx pgx.Tx, bannerID int, variants []structs.BannerVariant) ([]structs.BannerVariant, error) {
+	if _, err := tx.Exec(ctx, `DELETE FROM banner_variants WHERE banner_id = $1`, bannerID); err != nil {
+		br.log.Error("Failed to delete banner variants", errMsg.Err(err))
+		return nil, err
+	}
+
+	saved := make([]structs.BannerVariant, 0, len(variants))
+	for _, variant := range variants {
+		variant.BannerID = bannerID
+		err := tx.QueryRow(ctx,
+			`INSERT INTO banner_variants (banner_id, content, weight) VALUES ($1, $2, $3) RETURNING id`,
+			bannerID, variant.Content, variant.Weight).Scan(&variant.ID)
+		if err != nil {
+			br.log.Error("Failed to insert banner variant", errMsg.Err(err))
+			return nil, err
+		}
+		saved = append(saved, variant)
+	}
+	return saved, nil
+}
@@ -3,33 +3,71 @@ package crud
 import (
 	errMsg "banner-serivce/internal/api/err"
 	bannerhandlers "banner-serivce/internal/handlers/banner_handlers"
+	"banner-serivce/internal/metrics"
+	"banner-serivce/internal/storage"
 	"banner-serivce/internal/structs"
 	"context"
-	"database/sql"
+	"encoding/json"
 	"errors"
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"log/slog"
 	"strconv"
+	"time"
 )
 
+// minKeptRevisions is the number of revisions per banner that are never pruned,
+// so a banner can always be rolled back at least this many versions.
+const minKeptRevisions = 3
+
+// bannerFeatureTagKey is the unique index that keeps a feature and tag pair
+// from being claimed by more than one banner.
+const bannerFeatureTagKey = "banner_tags_feature_id_tag_id_key"
+
+// bannerVariantsColumn selects the A/B variants of banner b as a JSON array.
+const bannerVariantsColumn = `COALESCE((SELECT json_agg(json_build_object(
+				'variant_id', v.id,
+				'banner_id', v.banner_id,
+				'content', v.content,
+				'weight', v.weight) ORDER BY v.id)
+			FROM banner_variants v
+			WHERE v.banner_id = b.id), '[]')`
+
 type BannerRepository struct {
-	db  *pgxpool.Pool
-	log *slog.Logger
+	db            *pgxpool.Pool
+	log           *slog.Logger
+	keepRevisions int
 }
 
-func NewBannerRepository(db *pgxpool.Pool, log *slog.Logger) *BannerRepository {
-	return &BannerRepository{db, log}
+func NewBannerRepository(db *pgxpool.Pool, log *slog.Logger, keepRevisions int) *BannerRepository {
+	if keepRevisions < minKeptRevisions {
+		keepRevisions = minKeptRevisions
+	}
+	return &BannerRepository{db, log, keepRevisions}
 }
 
 func (br *BannerRepository) CreateBanner(ctx context.Context, banner *structs.Banner) error {
+	defer metrics.ObserveRepository("banner", "CreateBanner", time.Now())
+
+	tx, err := br.db.Begin(ctx)
+	if err != nil {
+		br.log.Error("Failed to begin transaction", errMsg.Err(err))
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := br.checkReferences(ctx, tx, banner); err != nil {
+		return err
+	}
 
-	err := br.db.QueryRow(ctx,
+	err = tx.QueryRow(ctx,
 		`INSERT INTO banners
 		(
 					feature_id,
 					content,
 					is_active,
+					active_from,
+					active_until,
 					created_at,
 					updated_at
 		)
@@ -39,145 +77,258 @@ func (br *BannerRepository) CreateBanner(ctx context.Context, banner *structs.Ba
 					$2,
 					$3,
 					$4,
-					$5
+					$5,
+					$6,
+					$7
 		)
-		returning id`,
+		returning id, version`,
 		banner.FeatureID,
 		banner.Content,
 		banner.IsActive,
+		banner.ActiveFrom,
+		banner.ActiveUntil,
 		banner.CreatedAt,
 		banner.UpdatedAt,
-	).Scan(&banner.ID)
+	).Scan(&banner.ID, &banner.Version)
 	if err != nil {
+		if _, ok := pgError(err, foreignKeyViolation); ok {
+			return &storage.UnknownReferencesError{FeatureID: &banner.FeatureID}
+		}
 		br.log.Error("failed to create banner", errMsg.Err(err))
 		return err
 	}
+
+	if err := br.insertBannerTags(ctx, tx, banner); err != nil {
+		return err
+	}
+
+	if err := br.saveRevision(ctx, tx, banner); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		br.log.Error("Failed to commit transaction", errMsg.Err(err))
+		return err
+	}
 	return nil
 }
 
 func (br *BannerRepository) FindBannerByID(ctx context.Context, id int) (structs.Banner, error) {
+	defer metrics.ObserveRepository("banner", "FindBannerByID", time.Now())
 
 	var banner structs.Banner
 
-	row := br.db.QueryRow(ctx, `SELECT & FROM banners WHERE id = $1`, id)
+	row := br.db.QueryRow(ctx, `SELECT b.id, b.feature_id, b.content, b.is_active, b.active_from, b.active_until, b.version, b.created_at, b.updated_at,
+		COALESCE(array_agg(bt.tag_id) FILTER (WHERE bt.tag_id IS NOT NULL), '{}') AS tag_ids
+		FROM banners b LEFT JOIN banner_tags bt ON b.id = bt.banner_id
+		WHERE b.id = $1
+		GROUP BY b.id`, id)
 
-	err := row.Scan(&banner.ID, &banner.FeatureID, &banner.Content, &banner.IsActive, &banner.CreatedAt, &banner.UpdatedAt)
+	err := row.Scan(&banner.ID, &banner.FeatureID, &banner.Content, &banner.IsActive, &banner.ActiveFrom, &banner.ActiveUntil, &banner.Version, &banner.CreatedAt, &banner.UpdatedAt, &banner.TagIDs)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return structs.Banner{}, err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return structs.Banner{}, storage.ErrBannerNotFound
 		}
 		br.log.Error("failed to find banner row", errMsg.Err(err))
+		return structs.Banner{}, err
 	}
+	banner = banner.ScheduleInUTC()
 
 	return banner, nil
 
 }
 
-func (br *BannerRepository) FindBannerByFeatureID(ctx context.Context, feature_id int) ([]structs.Banner, error) {
-	query, err := br.db.Query(ctx, `SELECT * FROM banners WHERE feature_id = $1`, feature_id)
+// FindBannerByFeatureTag returns the banner with its activation window and
+// A/B variants but does not filter by the window: the result is cached, so
+// whether the banner is live is decided when it is served.
+func (br *BannerRepository) FindBannerByFeatureTag(ctx context.Context, featureID, tagID int) (*structs.Banner, error) {
+	defer metrics.ObserveRepository("banner", "FindBannerByFeatureTag", time.Now())
+
+	query := `SELECT b.id,
+	b.feature_id,
+	b.content,
+	b.is_active,
+	b.active_from,
+	b.active_until,
+	b.version,
+	b.created_at,
+	b.updated_at,
+	` + bannerVariantsColumn + `
+FROM   banners b
+	INNER JOIN banner_tags bt
+			ON b.id = bt.banner_id
+WHERE  b.feature_id = $1
+	AND bt.tag_id = $2`
+
+	row := br.db.QueryRow(ctx, query, featureID, tagID)
+
+	var banner structs.Banner
+
+	err := row.Scan(&banner.ID, &banner.FeatureID, &banner.Content, &banner.IsActive, &banner.ActiveFrom, &banner.ActiveUntil, &banner.Version, &banner.CreatedAt, &banner.UpdatedAt, &banner.Variants)
 	if err != nil {
-		br.log.Error("Error querying banners", errMsg.Err(err))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrBannerNotFound
+		}
+		br.log.Error("Failed to find banner", errMsg.Err(err))
 		return nil, err
 	}
+	banner = banner.ScheduleInUTC()
 
-	defer query.Close()
+	return &banner, nil
+}
 
-	var bannersArr []structs.Banner
+// FindBannersByFeatureTags looks up the banners of several feature and tag
+// pairs with one query. Pairs without a banner are left out of the result.
+func (br *BannerRepository) FindBannersByFeatureTags(ctx context.Context, pairs []structs.FeatureTag) (map[structs.FeatureTag]structs.Banner, error) {
+	defer metrics.ObserveRepository("banner", "FindBannersByFeatureTags", time.Now())
 
-	for query.Next() {
-		var bannerRow structs.Banner
+	featureIDs := make([]int, len(pairs))
+	tagIDs := make([]int, len(pairs))
+	for i, pair := range pairs {
+		featureIDs[i], tagIDs[i] = pair.FeatureID, pair.TagID
+	}
 
-		err := query.Scan(&bannerRow.ID, &bannerRow.FeatureID, &bannerRow.Content, &bannerRow.IsActive, &bannerRow.CreatedAt, &bannerRow.UpdatedAt)
-		if err != nil {
-			br.log.Error("failed to scan banners", errMsg.Err(err))
+	rows, err := br.db.Query(ctx, `SELECT bt.tag_id,
+	b.id,
+	b.feature_id,
+	b.content,
+	b.is_active,
+	b.active_from,
+	b.active_until,
+	b.version,
+	b.created_at,
+	b.updated_at,
+	`+bannerVariantsColumn+`
+FROM   unnest($1::integer[], $2::integer[]) AS p(feature_id, tag_id)
+	INNER JOIN banner_tags bt
+			ON bt.feature_id = p.feature_id
+			AND bt.tag_id = p.tag_id
+	INNER JOIN banners b
+			ON b.id = bt.banner_id`, featureIDs, tagIDs)
+	if err != nil {
+		br.log.Error("Failed to query banners", errMsg.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	banners := make(map[structs.FeatureTag]structs.Banner, len(pairs))
+	for rows.Next() {
+		var tagID int
+		var banner structs.Banner
+		if err := rows.Scan(&tagID, &banner.ID, &banner.FeatureID, &banner.Content, &banner.IsActive, &banner.ActiveFrom, &banner.ActiveUntil, &banner.Version, &banner.CreatedAt, &banner.UpdatedAt, &banner.Variants); err != nil {
+			br.log.Error("Failed to scan banner row", errMsg.Err(err))
 			return nil, err
 		}
-		bannersArr = append(bannersArr, bannerRow)
+		banner = banner.ScheduleInUTC()
+		banners[structs.FeatureTag{FeatureID: banner.FeatureID, TagID: tagID}] = banner
 	}
 
-	if len(bannersArr) == 0 {
-		br.log.Info("No banners were found for feature ID:", feature_id)
-		return []structs.Banner{}, nil
+	if err := rows.Err(); err != nil {
+		br.log.Error("Error occurred while iterating banners", errMsg.Err(err))
+		return nil, err
 	}
 
-	return bannersArr, nil
+	return banners, nil
 }
 
-func (br *BannerRepository) FindBannerByTagID(ctx context.Context, tag_id int) ([]structs.Banner, error) {
+// DeleteBannerByID deletes the banner. When ifVersions is not nil the banner
+// is only deleted if its current version is one of them.
+func (br *BannerRepository) DeleteBannerByID(ctx context.Context, id int, ifVersions []int) error {
+	defer metrics.ObserveRepository("banner", "DeleteBannerByID", time.Now())
 
-	query, err := br.db.Query(ctx, `SELECT * FROM banner_tags WHERE tag_id = $1`, tag_id)
+	result, err := br.db.Exec(ctx,
+		`DELETE FROM banners WHERE id = $1 AND ($2::integer[] IS NULL OR version = ANY($2))`, id, ifVersions)
 	if err != nil {
-		br.log.Error("Error querying banners", errMsg.Err(err))
-		return nil, err
+		br.log.Error("failed to delete banner", errMsg.Err(err))
+		return err
 	}
+	if result.RowsAffected() == 0 {
+		return br.missingOrMismatched(ctx, id)
+	}
+	return nil
+}
 
-	defer query.Close()
+// FindBannerIDs returns the ids of banners matching the feature and/or tag.
+func (br *BannerRepository) FindBannerIDs(ctx context.Context, featureID, tagID *int) ([]int, error) {
+	defer metrics.ObserveRepository("banner", "FindBannerIDs", time.Now())
 
-	var bannersArr []structs.Banner
+	query := "SELECT b.id FROM banners b WHERE 1=1"
+	args := []interface{}{}
 
-	for query.Next() {
-		var bannerRow structs.Banner
+	if featureID != nil {
+		query += " AND b.feature_id = $" + strconv.Itoa(len(args)+1)
+		args = append(args, *featureID)
+	}
 
-		err := query.Scan(&bannerRow.ID, &bannerRow.FeatureID, &bannerRow.Content, &bannerRow.IsActive, &bannerRow.CreatedAt, &bannerRow.UpdatedAt)
-		if err != nil {
-			br.log.Error("failed to scan banners", errMsg.Err(err))
+	if tagID != nil {
+		query += " AND b.id IN (SELECT banner_id FROM banner_tags WHERE tag_id = $" + strconv.Itoa(len(args)+1) + ")"
+		args = append(args, *tagID)
+	}
+
+	query += " ORDER BY b.id"
+
+	rows, err := br.db.Query(ctx, query, args...)
+	if err != nil {
+		br.log.Error("Failed to query banner ids", errMsg.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			br.log.Error("Failed to scan banner id", errMsg.Err(err))
 			return nil, err
 		}
-		bannersArr = append(bannersArr, bannerRow)
+		ids = append(ids, id)
 	}
 
-	if len(bannersArr) == 0 {
-		br.log.Info("No banners were found for tag ID:", tag_id)
-		return []structs.Banner{}, nil
+	if err := rows.Err(); err != nil {
+		br.log.Error("Error occurred while iterating banner ids", errMsg.Err(err))
+		return nil, err
 	}
 
-	return bannersArr, nil
+	return ids, nil
 }
 
-func (br *BannerRepository) FindBannerByFeatureTag(ctx context.Context, featureID, tagID int) (*structs.Banner, error) {
-
-	query := `SELECT b.id,
-	b.feature_id,
-	b.content,
-	b.is_active,
-	b.created_at,
-	b.updated_at
-FROM   banners b
-	INNER JOIN banner_tags bt
-			ON b.id = bt.banner_id
-WHERE  b.feature_id = $1
-	AND bt.tag_id = $2
-	AND b.is_active = true`
-
-	row := br.db.QueryRow(ctx, query, featureID, tagID)
-
-	var banner structs.Banner
+func (br *BannerRepository) FindBannersByParameters(ctx context.Context, params bannerhandlers.RequestGetBanners) ([]structs.Banner, error) {
+	defer metrics.ObserveRepository("banner", "FindBannersByParameters", time.Now())
 
-	err := row.Scan(&banner.ID, &banner.FeatureID, &banner.Content, &banner.IsActive, &banner.CreatedAt, &banner.UpdatedAt)
+	query, args := bannersQuery(params)
+	rows, err := br.db.Query(ctx, query, args...)
 	if err != nil {
-		br.log.Error("Error with database", errMsg.Err(err))
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, err
-		}
-		br.log.Error("Failed to find banner", errMsg.Err(err))
+		br.log.Error("Failed to query banners", errMsg.Err(err))
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &banner, nil
-}
+	var banners []structs.Banner
+	for rows.Next() {
+		var banner structs.Banner
+		var tagIDs []int
+		if err := rows.Scan(&banner.ID, &banner.FeatureID, &banner.Content, &banner.IsActive, &banner.ActiveFrom, &banner.ActiveUntil, &banner.Version, &banner.CreatedAt, &banner.UpdatedAt, &tagIDs); err != nil {
+			br.log.Error("Failed to scan banner row", errMsg.Err(err))
+			return nil, err
+		}
+		banner = banner.ScheduleInUTC()
+		banner.TagIDs = tagIDs
+		banners = append(banners, banner)
+	}
 
-func (br *BannerRepository) DeleteBannerByID(ctx context.Context, id int) error {
-	_, err := br.db.Exec(ctx, `DELETE FROM banners WHERE id = $1`, id)
-	if err != nil {
-		br.log.Error("failed to delete banner", errMsg.Err(err))
-		return err
+	if err := rows.Err(); err != nil {
+		br.log.Error("Error occurred while iterating banner rows", errMsg.Err(err))
+		return nil, err
 	}
-	return nil
+
+	return banners, nil
 }
 
-func (br *BannerRepository) FindBannersByParameters(ctx context.Context, params bannerhandlers.RequestGetBanners) ([]structs.Banner, error) {
-	query := "SELECT b.id, b.feature_id, b.content, b.is_active, b.created_at, b.updated_at, array_agg(bt.tag_id) AS tag_ids FROM banners b LEFT JOIN banner_tags bt ON b.id = bt.banner_id WHERE 1=1"
+// bannersQuery builds the query FindBannersByParameters runs. Every filter,
+// the schedule state included, goes into WHERE before the rows are grouped.
+func bannersQuery(params bannerhandlers.RequestGetBanners) (string, []interface{}) {
+	query := "SELECT b.id, b.feature_id, b.content, b.is_active, b.active_from, b.active_until, b.version, b.created_at, b.updated_at, COALESCE(array_agg(bt.tag_id) FILTER (WHERE bt.tag_id IS NOT NULL), '{}') AS tag_ids FROM banners b LEFT JOIN banner_tags bt ON b.id = bt.banner_id WHERE 1=1"
 	args := []interface{}{}
 
 	if params.FeatureID != nil {
@@ -190,7 +341,16 @@ func (br *BannerRepository) FindBannersByParameters(ctx context.Context, params
 		args = append(args, *params.TagID)
 	}
 
-	query += " GROUP BY b.id"
+	switch params.State {
+	case structs.ScheduleLive:
+		query += " AND b.is_active AND (b.active_from IS NULL OR b.active_from <= now()) AND (b.active_until IS NULL OR b.active_until > now())"
+	case structs.ScheduleUpcoming:
+		query += " AND b.active_from > now()"
+	case structs.ScheduleExpired:
+		query += " AND b.active_until <= now()"
+	}
+
+	query += " GROUP BY b.id ORDER BY b.id"
 
 	if params.Limit != nil {
 		query += " LIMIT $" + strconv.Itoa(len(args)+1)
@@ -202,66 +362,296 @@ func (br *BannerRepository) FindBannersByParameters(ctx context.Context, params
 		args = append(args, *params.Offset)
 	}
 
-	rows, err := br.db.Query(ctx, query, args...)
+	return query, args
+}
+
+// UpdateBanner overwrites the banner with a new revision. When ifVersions is
+// not nil the update only happens if the current version is one of them.
+func (br *BannerRepository) UpdateBanner(ctx context.Context, banner *structs.Banner, ifVersions []int) error {
+	defer metrics.ObserveRepository("banner", "UpdateBanner", time.Now())
+
+	tx, err := br.db.Begin(ctx)
 	if err != nil {
-		br.log.Error("Failed to query banners", errMsg.Err(err))
+		br.log.Error("Failed to begin transaction", errMsg.Err(err))
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := br.updateBanner(ctx, tx, banner, ifVersions); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		br.log.Error("Failed to commit transaction", errMsg.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+func (br *BannerRepository) FindBannerRevisions(ctx context.Context, bannerID int) ([]structs.BannerRevision, error) {
+	defer metrics.ObserveRepository("banner", "FindBannerRevisions", time.Now())
+
+	var exists bool
+	err := br.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM banners WHERE id = $1)`, bannerID).Scan(&exists)
+	if err != nil {
+		br.log.Error("Failed to check banner", errMsg.Err(err))
+		return nil, err
+	}
+	if !exists {
+		return nil, storage.ErrBannerNotFound
+	}
+
+	rows, err := br.db.Query(ctx,
+		`SELECT banner_id, version, feature_id, tag_ids, content, is_active, active_from, active_until, created_at
+		FROM banner_revisions
+		WHERE banner_id = $1
+		ORDER BY version DESC`, bannerID)
+	if err != nil {
+		br.log.Error("Failed to query banner revisions", errMsg.Err(err))
 		return nil, err
 	}
 	defer rows.Close()
 
-	var banners []structs.Banner
+	revisions := []structs.BannerRevision{}
 	for rows.Next() {
-		var banner structs.Banner
-		var tagIDs []int
-		if err := rows.Scan(&banner.ID, &banner.FeatureID, &banner.Content, &banner.IsActive, &banner.CreatedAt, &banner.UpdatedAt, &tagIDs); err != nil {
-			br.log.Error("Failed to scan banner row", errMsg.Err(err))
+		var revision structs.BannerRevision
+		if err := rows.Scan(&revision.BannerID, &revision.Version, &revision.FeatureID, &revision.TagIDs, &revision.Content, &revision.IsActive, &revision.ActiveFrom, &revision.ActiveUntil, &revision.CreatedAt); err != nil {
+			br.log.Error("Failed to scan banner revision", errMsg.Err(err))
 			return nil, err
 		}
-		banner.TagIDs = tagIDs
-		banners = append(banners, banner)
+		revision.ActiveFrom = structs.InUTC(revision.ActiveFrom)
+		revision.ActiveUntil = structs.InUTC(revision.ActiveUntil)
+		revisions = append(revisions, revision)
 	}
 
 	if err := rows.Err(); err != nil {
-		br.log.Error("Error occurred while iterating banner rows", errMsg.Err(err))
+		br.log.Error("Error occurred while iterating banner revisions", errMsg.Err(err))
 		return nil, err
 	}
 
-	return banners, nil
+	return revisions, nil
 }
 
-func (br *BannerRepository) UpdateBanner(ctx context.Context, banner *structs.Banner) error {
+// ActivateBannerRevision restores the banner to the state stored in the given
+// revision, variants included. The restore itself is recorded as a new
+// revision. When ifVersions is not nil the banner is only restored if its
+// current version is one of them.
+func (br *BannerRepository) ActivateBannerRevision(ctx context.Context, bannerID, version int, ifVersions []int) (structs.Banner, error) {
+	defer metrics.ObserveRepository("banner", "ActivateBannerRevision", time.Now())
 
 	tx, err := br.db.Begin(ctx)
 	if err != nil {
 		br.log.Error("Failed to begin transaction", errMsg.Err(err))
-		return err
+		return structs.Banner{}, err
 	}
 	defer tx.Rollback(ctx)
 
-	_, err = tx.Exec(ctx, `DELETE FROM banner_tags WHERE banner_id = $1`, banner.ID)
+	banner := structs.Banner{ID: bannerID}
+	var variants []byte
+	err = tx.QueryRow(ctx,
+		`SELECT b.created_at, r.feature_id, r.tag_ids, r.content, r.is_active, r.active_from, r.active_until, r.variants
+		FROM banners b
+			INNER JOIN banner_revisions r
+					ON b.id = r.banner_id
+		WHERE b.id = $1
+			AND r.version = $2
+		FOR UPDATE OF b`, bannerID, version).
+		Scan(&banner.CreatedAt, &banner.FeatureID, &banner.TagIDs, &banner.Content, &banner.IsActive, &banner.ActiveFrom, &banner.ActiveUntil, &variants)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return structs.Banner{}, storage.ErrRevisionNotFound
+		}
+		br.log.Error("Failed to find banner revision", errMsg.Err(err))
+		return structs.Banner{}, err
+	}
+
+	// Revisions older than migration 0012 did not record variants, and
+	// restoring one keeps the current variants.
+	if variants != nil {
+		var restored []structs.BannerVariant
+		if err := json.Unmarshal(variants, &restored); err != nil {
+			br.log.Error("Failed to decode revision variants", errMsg.Err(err))
+			return structs.Banner{}, err
+		}
+		if _, err := br.replaceBannerVariants(ctx, tx, bannerID, restored); err != nil {
+			return structs.Banner{}, err
+		}
+	}
+	banner = banner.ScheduleInUTC()
+	banner.UpdatedAt = time.Now()
+
+	if err := br.updateBanner(ctx, tx, &banner, ifVersions); err != nil {
+		return structs.Banner{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		br.log.Error("Failed to commit transaction", errMsg.Err(err))
+		return structs.Banner{}, err
+	}
+
+	return banner, nil
+}
+
+func (br *BannerRepository) updateBanner(ctx context.Context, tx pgx.Tx, banner *structs.Banner, ifVersions []int) error {
+	if err := br.checkReferences(ctx, tx, banner); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, `DELETE FROM banner_tags WHERE banner_id = $1`, banner.ID)
 	if err != nil {
 		br.log.Error("failed to delete old tags for banner", errMsg.Err(err))
 		return err
 	}
 
+	err = tx.QueryRow(ctx,
+		`UPDATE banners SET feature_id = $1, content = $2, is_active = $3, active_from = $4, active_until = $5, updated_at = $6, version = version + 1
+		WHERE id = $7 AND ($8::integer[] IS NULL OR version = ANY($8))
+		RETURNING version`,
+		banner.FeatureID, banner.Content, banner.IsActive, banner.ActiveFrom, banner.ActiveUntil, banner.UpdatedAt, banner.ID, ifVersions).Scan(&banner.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return br.missingOrMismatched(ctx, banner.ID)
+		}
+		if _, ok := pgError(err, foreignKeyViolation); ok {
+			return &storage.UnknownReferencesError{FeatureID: &banner.FeatureID}
+		}
+		br.log.Error("Failed to update banner", errMsg.Err(err))
+		return err
+	}
+
+	if err := br.insertBannerTags(ctx, tx, banner); err != nil {
+		return err
+	}
+
+	return br.saveRevision(ctx, tx, banner)
+}
+
+// insertBannerTags links the banner to its tags. The unique index on
+// (feature_id, tag_id) rejects a pair that another banner already owns, which
+// is reported as a *storage.BannerConflictError.
+func (br *BannerRepository) insertBannerTags(ctx context.Context, tx pgx.Tx, banner *structs.Banner) error {
 	for _, tagID := range banner.TagIDs {
-		_, err = tx.Exec(ctx, `INSERT INTO banner_tags (banner_id, tag_id) VALUES ($1, $2)`, banner.ID, tagID)
+		_, err := tx.Exec(ctx, `INSERT INTO banner_tags (banner_id, feature_id, tag_id) VALUES ($1, $2, $3)`,
+			banner.ID, banner.FeatureID, tagID)
 		if err != nil {
+			mapped := bannerTagError(err, banner.FeatureID, tagID)
+			var conflict *storage.BannerConflictError
+			if errors.As(mapped, &conflict) {
+				br.findConflictingBanner(ctx, conflict)
+				return conflict
+			}
+			if mapped != nil {
+				return mapped
+			}
 			br.log.Error("failed to insert tag for banner", errMsg.Err(err))
 			return err
 		}
 	}
+	return nil
+}
+
+// bannerTagError maps a failed banner_tags insert to the error reported to
+// callers, or returns nil when err is not one the caller can act on.
+func bannerTagError(err error, featureID, tagID int) error {
+	if pgErr, ok := pgError(err, uniqueViolation); ok && pgErr.ConstraintName == bannerFeatureTagKey {
+		return &storage.BannerConflictError{FeatureID: featureID, TagID: tagID}
+	}
+	if _, ok := pgError(err, foreignKeyViolation); ok {
+		return &storage.UnknownReferencesError{TagIDs: []int{tagID}}
+	}
+	return nil
+}
+
+// missingOrMismatched explains why a conditional write to the banner touched
+// no rows.
+func (br *BannerRepository) missingOrMismatched(ctx context.Context, id int) error {
+	var exists bool
+	err := br.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM banners WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		br.log.Error("Failed to check banner", errMsg.Err(err))
+		return err
+	}
+	if !exists {
+		return storage.ErrBannerNotFound
+	}
+	return storage.ErrVersionMismatch
+}
 
-	_, err = tx.Exec(ctx,
-		`UPDATE banners SET feature_id = $1, content = $2, is_active = $3, updated_at = $4 WHERE id = $5`,
-		banner.FeatureID, banner.Content, banner.IsActive, banner.UpdatedAt, banner.ID)
+// checkReferences makes sure the feature and every tag of the banner exist
+// before anything is written, so the caller can report all unknown ids at
+// once instead of failing on the first one.
+func (br *BannerRepository) checkReferences(ctx context.Context, tx pgx.Tx, banner *structs.Banner) error {
+	var featureExists bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM features WHERE id = $1)`, banner.FeatureID).Scan(&featureExists)
 	if err != nil {
-		br.log.Error("Failed to update banner", errMsg.Err(err))
+		br.log.Error("Failed to check feature", errMsg.Err(err))
 		return err
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		br.log.Error("Failed to commit transaction", errMsg.Err(err))
+	tagIDs := banner.TagIDs
+	if tagIDs == nil {
+		tagIDs = []int{}
+	}
+	rows, err := tx.Query(ctx,
+		`SELECT t.id
+		FROM unnest($1::integer[]) AS t(id)
+		WHERE NOT EXISTS (SELECT 1 FROM tags WHERE tags.id = t.id)
+		ORDER BY t.id`, tagIDs)
+	if err != nil {
+		br.log.Error("Failed to check tags", errMsg.Err(err))
+		return err
+	}
+	unknownTags, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		br.log.Error("Failed to scan unknown tags", errMsg.Err(err))
+		return err
+	}
+
+	if featureExists && len(unknownTags) == 0 {
+		return nil
+	}
+	unknown := &storage.UnknownReferencesError{TagIDs: unknownTags}
+	if !featureExists {
+		unknown.FeatureID = &banner.FeatureID
+	}
+	return unknown
+}
+
+// findConflictingBanner fills in the banner that owns the pair of conflict.
+func (br *BannerRepository) findConflictingBanner(ctx context.Context, conflict *storage.BannerConflictError) {
+	err := br.db.QueryRow(ctx, `SELECT banner_id FROM banner_tags WHERE feature_id = $1 AND tag_id = $2`,
+		conflict.FeatureID, conflict.TagID).Scan(&conflict.BannerID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		br.log.Error("Failed to find conflicting banner", errMsg.Err(err))
+	}
+}
+
+// saveRevision records the current state of the banner, its variants as they
+// are in the table at this point of tx included, and prunes revisions
+// that fall outside the retention window.
+func (br *BannerRepository) saveRevision(ctx context.Context, tx pgx.Tx, banner *structs.Banner) error {
+	tagIDs := banner.TagIDs
+	if tagIDs == nil {
+		tagIDs = []int{}
+	}
+
+	_, err := tx.Exec(ctx,
+		`INSERT INTO banner_revisions (banner_id, version, feature_id, tag_ids, content, is_active, active_from, active_until, created_at, variants)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, (
+			SELECT COALESCE(jsonb_agg(jsonb_build_object('variant_id', id, 'banner_id', banner_id, 'content', content, 'weight', weight) ORDER BY id), '[]')
+			FROM banner_variants
+			WHERE banner_id = $1))`,
+		banner.ID, banner.Version, banner.FeatureID, tagIDs, banner.Content, banner.IsActive, banner.ActiveFrom, banner.ActiveUntil, banner.UpdatedAt)
+	if err != nil {
+		br.log.Error("Failed to save banner revision", errMsg.Err(err))
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM banner_revisions WHERE banner_id = $1 AND version <= $2`,
+		banner.ID, banner.Version-br.keepRevisions)
+	if err != nil {
+		br.log.Error("Failed to prune banner revisions", errMsg.Err(err))
 		return err
 	}
 
@@ -0,0 +1,195 @@
+package crud
+
+import (
+	"banner-serivce/internal/db/postgresql"
+	bannerhandlers "banner-serivce/internal/handlers/banner_handlers"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestBannerTagError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantIs   error
+		wantNone bool
+	}{
+		{
+			name:   "feature and tag pair taken",
+			err:    &pgconn.PgError{Code: uniqueViolation, ConstraintName: bannerFeatureTagKey},
+			wantIs: storage.ErrBannerConflict,
+		},
+		{
+			name:   "wrapped feature and tag pair taken",
+			err:    fmt.Errorf("insert: %w", &pgconn.PgError{Code: uniqueViolation, ConstraintName: bannerFeatureTagKey}),
+			wantIs: storage.ErrBannerConflict,
+		},
+		{
+			name:     "tag linked twice to the same banner",
+			err:      &pgconn.PgError{Code: uniqueViolation, ConstraintName: "banner_tags_pkey"},
+			wantNone: true,
+		},
+		{
+			name:   "unknown tag",
+			err:    &pgconn.PgError{Code: foreignKeyViolation, ConstraintName: "banner_tags_tag_id_fkey"},
+			wantIs: storage.ErrUnknownReferences,
+		},
+		{
+			name:     "other error",
+			err:      errors.New("connection reset"),
+			wantNone: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bannerTagError(tt.err, 3, 7)
+			if tt.wantNone {
+				if got != nil {
+					t.Fatalf("bannerTagError() = %v, want nil", got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.wantIs) {
+				t.Fatalf("bannerTagError() = %v, want %v", got, tt.wantIs)
+			}
+			var conflict *storage.BannerConflictError
+			if errors.As(got, &conflict) && (conflict.FeatureID != 3 || conflict.TagID != 7) {
+				t.Errorf("conflict = %+v, want feature 3 and tag 7", conflict)
+			}
+		})
+	}
+}
+
+func TestBannersQueryFiltersBeforeGrouping(t *testing.T) {
+	featureID, tagID, limit, offset := 1, 2, 10, 20
+	states := []string{"", structs.ScheduleLive, structs.ScheduleUpcoming, structs.ScheduleExpired}
+
+	for _, state := range states {
+		t.Run("state="+state, func(t *testing.T) {
+			query, args := bannersQuery(bannerhandlers.RequestGetBanners{
+				FeatureID: &featureID,
+				TagID:     &tagID,
+				Limit:     &limit,
+				Offset:    &offset,
+				State:     state,
+			})
+
+			where := strings.Index(query, " WHERE ")
+			groupBy := strings.Index(query, " GROUP BY ")
+			if where < 0 || groupBy < where {
+				t.Fatalf("query has no WHERE before GROUP BY: %s", query)
+			}
+			if strings.Contains(query[groupBy:], "now()") {
+				t.Errorf("state predicate after GROUP BY: %s", query)
+			}
+			if state != "" && !strings.Contains(query[where:groupBy], "now()") {
+				t.Errorf("state predicate missing from WHERE: %s", query)
+			}
+			if !strings.HasSuffix(query, " LIMIT $3 OFFSET $4") {
+				t.Errorf("query does not end with LIMIT and OFFSET: %s", query)
+			}
+			if want := []interface{}{featureID, tagID, limit, offset}; !slices.Equal(args, want) {
+				t.Errorf("args = %v, want %v", args, want)
+			}
+		})
+	}
+}
+
+// TestFindBannersByParametersState runs each state against a real database.
+// It needs TEST_DATABASE_URL pointing at a database it may migrate and write
+// to, and is skipped otherwise.
+func TestFindBannersByParametersState(t *testing.T) {
+	connString := os.Getenv("TEST_DATABASE_URL")
+	if connString == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db, err := pgxpool.New(ctx, connString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migrator, err := postgresql.NewMigrator(db, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	feature := structs.Feature{Name: "state-test-" + suffix}
+	if err := NewFeatureRepository(db, log).CreateFeature(ctx, &feature); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(ctx, `DELETE FROM banners WHERE feature_id = $1`, feature.ID)
+		db.Exec(ctx, `DELETE FROM features WHERE id = $1`, feature.ID)
+	})
+
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	windows := map[string][2]*time.Time{
+		structs.ScheduleLive:     {&past, &future},
+		structs.ScheduleUpcoming: {&future, nil},
+		structs.ScheduleExpired:  {nil, &past},
+	}
+
+	tags := NewTagRepository(db, log)
+	banners := NewBannerRepository(db, log, 0)
+	ids := map[string]int{}
+	for state, window := range windows {
+		tag := structs.Tag{Name: "state-test-" + state + "-" + suffix}
+		if err := tags.CreateTag(ctx, &tag); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			db.Exec(ctx, `DELETE FROM tags WHERE id = $1`, tag.ID)
+		})
+
+		banner := structs.Banner{
+			FeatureID:   feature.ID,
+			TagIDs:      []int{tag.ID},
+			Content:     map[string]interface{}{"title": state},
+			IsActive:    true,
+			ActiveFrom:  window[0],
+			ActiveUntil: window[1],
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := banners.CreateBanner(ctx, &banner); err != nil {
+			t.Fatal(err)
+		}
+		ids[state] = banner.ID
+	}
+
+	for state, want := range ids {
+		t.Run(state, func(t *testing.T) {
+			found, err := banners.FindBannersByParameters(ctx, bannerhandlers.RequestGetBanners{FeatureID: &feature.ID, State: state})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(found) != 1 || found[0].ID != want {
+				t.Errorf("found %+v, want only banner %d", found, want)
+			}
+		})
+	}
+}
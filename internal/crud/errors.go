@@ -0,0 +1,34 @@
+package crud
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// pgError returns the Postgres error behind err when it has the given code.
+func pgError(err error, code string) (*pgconn.PgError, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == code {
+		return pgErr, true
+	}
+	return nil, false
+}
+
+// likePrefix turns user input into a LIKE pattern matching it as a prefix.
+func likePrefix(prefix string) string {
+	var b []byte
+	for i := 0; i < len(prefix); i++ {
+		switch prefix[i] {
+		case '\\', '%', '_':
+			b = append(b, '\\')
+		}
+		b = append(b, prefix[i])
+	}
+	return string(b) + "%"
+}
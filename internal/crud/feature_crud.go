@@ -2,11 +2,18 @@ package crud
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	featurehandlers "banner-serivce/internal/handlers/feature_handlers"
+	"banner-serivce/internal/metrics"
+	"banner-serivce/internal/storage"
 	"banner-serivce/internal/structs"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -20,6 +27,8 @@ func NewFeatureRepository(db *pgxpool.Pool, log *slog.Logger) *FeatureRepository
 }
 
 func (fr *FeatureRepository) CreateFeature(ctx context.Context, feature *structs.Feature) error {
+	defer metrics.ObserveRepository("feature", "CreateFeature", time.Now())
+
 	err := fr.db.QueryRow(ctx, `INSERT INTO features (name) VALUES ($1) RETURNING id`, feature.Name).Scan(&feature.ID)
 	if err != nil {
 		fr.log.Error("failed creating feature", errMsg.Err(err))
@@ -29,13 +38,18 @@ func (fr *FeatureRepository) CreateFeature(ctx context.Context, feature *structs
 }
 
 func (fr *FeatureRepository) FindFeatureById(ctx context.Context, id int) (structs.Feature, error) {
+	defer metrics.ObserveRepository("feature", "FindFeatureById", time.Now())
+
 	var feature structs.Feature
 
 	row := fr.db.QueryRow(ctx, `SELECT id, name FROM features WHERE id = $1`, id)
 
-	err := row.Scan(&feature.ID, feature.Name)
+	err := row.Scan(&feature.ID, &feature.Name)
 
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return structs.Feature{}, storage.ErrFeatureNotFound
+		}
 		fr.log.Error("Failed to find Feature by ID", errMsg.Err(err))
 		return structs.Feature{}, err
 	}
@@ -44,6 +58,8 @@ func (fr *FeatureRepository) FindFeatureById(ctx context.Context, id int) (struc
 }
 
 func (fr *FeatureRepository) FindFeatureByName(ctx context.Context, name string) (structs.Feature, error) {
+	defer metrics.ObserveRepository("feature", "FindFeatureByName", time.Now())
+
 	query, err := fr.db.Query(ctx, `SELECT * FROM features WHERE name = $1`, name)
 	if err != nil {
 		fr.log.Error("Feature not found", errMsg.Err(err))
@@ -62,3 +78,75 @@ func (fr *FeatureRepository) FindFeatureByName(ctx context.Context, name string)
 	return row, nil
 
 }
+
+func (fr *FeatureRepository) FindFeaturesByParameters(ctx context.Context, params featurehandlers.RequestGetFeatures) ([]structs.Feature, error) {
+	defer metrics.ObserveRepository("feature", "FindFeaturesByParameters", time.Now())
+
+	query := "SELECT id, name FROM features WHERE 1=1"
+	args := []interface{}{}
+
+	if params.Prefix != "" {
+		query += " AND name LIKE $" + strconv.Itoa(len(args)+1)
+		args = append(args, likePrefix(params.Prefix))
+	}
+
+	query += " ORDER BY name, id LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+	args = append(args, params.Limit, params.Offset)
+
+	rows, err := fr.db.Query(ctx, query, args...)
+	if err != nil {
+		fr.log.Error("Failed to query features", errMsg.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	features := []structs.Feature{}
+	for rows.Next() {
+		var feature structs.Feature
+		if err := rows.Scan(&feature.ID, &feature.Name); err != nil {
+			fr.log.Error("Failed to scan feature row", errMsg.Err(err))
+			return nil, err
+		}
+		features = append(features, feature)
+	}
+
+	if err := rows.Err(); err != nil {
+		fr.log.Error("Error occurred while iterating feature rows", errMsg.Err(err))
+		return nil, err
+	}
+
+	return features, nil
+}
+
+func (fr *FeatureRepository) UpdateFeature(ctx context.Context, feature *structs.Feature) error {
+	defer metrics.ObserveRepository("feature", "UpdateFeature", time.Now())
+
+	err := fr.db.QueryRow(ctx, `UPDATE features SET name = $1 WHERE id = $2 RETURNING id`, feature.Name, feature.ID).Scan(&feature.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrFeatureNotFound
+		}
+		fr.log.Error("Failed to update feature", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+// DeleteFeature removes the feature. A feature that banners still reference
+// is kept and storage.ErrFeatureInUse is returned.
+func (fr *FeatureRepository) DeleteFeature(ctx context.Context, id int) error {
+	defer metrics.ObserveRepository("feature", "DeleteFeature", time.Now())
+
+	result, err := fr.db.Exec(ctx, `DELETE FROM features WHERE id = $1`, id)
+	if err != nil {
+		if _, ok := pgError(err, foreignKeyViolation); ok {
+			return storage.ErrFeatureInUse
+		}
+		fr.log.Error("Failed to delete feature", errMsg.Err(err))
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return storage.ErrFeatureNotFound
+	}
+	return nil
+}
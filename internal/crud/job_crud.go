@@ -0,0 +1,134 @@
+package crud
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/jobs"
+	"banner-serivce/internal/metrics"
+	"banner-serivce/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type JobRepository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func NewJobRepository(db *pgxpool.Pool, log *slog.Logger) *JobRepository {
+	return &JobRepository{db, log}
+}
+
+func (jr *JobRepository) CreateJob(ctx context.Context, job *jobs.Job) error {
+	defer metrics.ObserveRepository("job", "CreateJob", time.Now())
+
+	err := jr.db.QueryRow(ctx,
+		`INSERT INTO jobs (id, kind, params, status) VALUES ($1, $2, $3, $4) RETURNING created_at`,
+		job.ID, job.Kind, job.Params, job.Status).Scan(&job.CreatedAt)
+	if err != nil {
+		jr.log.Error("Failed to create job", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+func (jr *JobRepository) FindJob(ctx context.Context, id string) (jobs.Job, error) {
+	defer metrics.ObserveRepository("job", "FindJob", time.Now())
+
+	var job jobs.Job
+	err := jr.db.QueryRow(ctx,
+		`SELECT id, kind, params, status, total, done, attempts, COALESCE(error, ''), created_at, finished_at
+		FROM jobs WHERE id = $1`, id).
+		Scan(&job.ID, &job.Kind, &job.Params, &job.Status, &job.Total, &job.Done, &job.Attempts, &job.Error, &job.CreatedAt, &job.FinishedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return jobs.Job{}, storage.ErrJobNotFound
+		}
+		jr.log.Error("Failed to find job", errMsg.Err(err))
+		return jobs.Job{}, err
+	}
+	return job, nil
+}
+
+// ClaimJob takes the oldest claimable job. SKIP LOCKED lets workers of
+// several replicas claim jobs at the same time without picking the same one.
+func (jr *JobRepository) ClaimJob(ctx context.Context, kinds []string, staleBefore time.Time) (jobs.Job, bool, error) {
+	defer metrics.ObserveRepository("job", "ClaimJob", time.Now())
+
+	var job jobs.Job
+	err := jr.db.QueryRow(ctx,
+		`UPDATE jobs SET status = $1, attempts = attempts + 1, heartbeat_at = now()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE kind = ANY($2)
+				AND (status = $3 OR (status = $1 AND heartbeat_at < $4))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, kind, params, status, total, done, attempts, created_at`,
+		jobs.StatusRunning, kinds, jobs.StatusQueued, staleBefore).
+		Scan(&job.ID, &job.Kind, &job.Params, &job.Status, &job.Total, &job.Done, &job.Attempts, &job.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return jobs.Job{}, false, nil
+		}
+		jr.log.Error("Failed to claim job", errMsg.Err(err))
+		return jobs.Job{}, false, err
+	}
+	return job, true, nil
+}
+
+func (jr *JobRepository) UpdateJobProgress(ctx context.Context, id string, total, done int) error {
+	defer metrics.ObserveRepository("job", "UpdateJobProgress", time.Now())
+
+	_, err := jr.db.Exec(ctx,
+		`UPDATE jobs SET total = $1, done = $2, heartbeat_at = now() WHERE id = $3`, total, done, id)
+	if err != nil {
+		jr.log.Error("Failed to update job progress", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+func (jr *JobRepository) FinishJob(ctx context.Context, job jobs.Job) error {
+	defer metrics.ObserveRepository("job", "FinishJob", time.Now())
+
+	_, err := jr.db.Exec(ctx,
+		`UPDATE jobs SET status = $1, total = $2, done = $3, error = NULLIF($4, ''), finished_at = $5 WHERE id = $6`,
+		job.Status, job.Total, job.Done, job.Error, job.FinishedAt, job.ID)
+	if err != nil {
+		jr.log.Error("Failed to finish job", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+// RequeueJob hands a running job back to the queue. The attempt it used is
+// not counted, since the job was stopped rather than lost.
+func (jr *JobRepository) RequeueJob(ctx context.Context, id string) error {
+	defer metrics.ObserveRepository("job", "RequeueJob", time.Now())
+
+	_, err := jr.db.Exec(ctx,
+		`UPDATE jobs SET status = $1, attempts = attempts - 1, heartbeat_at = NULL WHERE id = $2`, jobs.StatusQueued, id)
+	if err != nil {
+		jr.log.Error("Failed to requeue job", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+func (jr *JobRepository) DeleteFinishedJobs(ctx context.Context, finishedBefore time.Time) error {
+	defer metrics.ObserveRepository("job", "DeleteFinishedJobs", time.Now())
+
+	_, err := jr.db.Exec(ctx, `DELETE FROM jobs WHERE finished_at < $1`, finishedBefore)
+	if err != nil {
+		jr.log.Error("Failed to delete finished jobs", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
@@ -2,12 +2,19 @@ package crud
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	taghandlers "banner-serivce/internal/handlers/tag_handlers"
+	"banner-serivce/internal/metrics"
+	"banner-serivce/internal/storage"
 	"banner-serivce/internal/structs"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"time"
 
 	"context"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -21,11 +28,16 @@ func NewTagRepository(db *pgxpool.Pool, log *slog.Logger) *TagRepository {
 }
 
 func (tr *TagRepository) CreateTag(ctx context.Context, tag *structs.Tag) error {
+	defer metrics.ObserveRepository("tag", "CreateTag", time.Now())
+
 	err := tr.db.QueryRow(ctx,
 		`INSERT INTO tags (name)
 		VALUES ($1)
 		RETURNING id`, tag.Name).Scan(&tag.ID)
 	if err != nil {
+		if _, ok := pgError(err, uniqueViolation); ok {
+			return storage.ErrTagExists
+		}
 		tr.log.Error("Failed to create tag", errMsg.Err(err))
 		return err
 	}
@@ -33,9 +45,14 @@ func (tr *TagRepository) CreateTag(ctx context.Context, tag *structs.Tag) error
 }
 
 func (tr *TagRepository) FindTagById(ctx context.Context, id int) (structs.Tag, error) {
+	defer metrics.ObserveRepository("tag", "FindTagById", time.Now())
+
 	var tag structs.Tag
 	err := tr.db.QueryRow(ctx, `SELECT id, name FROM tags WHERE id = $1`, id).Scan(&tag.ID, &tag.Name)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return structs.Tag{}, storage.ErrTagNotFound
+		}
 		tr.log.Error("Failed to find Tag by ID", errMsg.Err(err))
 		return structs.Tag{}, err
 	}
@@ -43,6 +60,8 @@ func (tr *TagRepository) FindTagById(ctx context.Context, id int) (structs.Tag,
 }
 
 func (tr *TagRepository) FindTagByName(ctx context.Context, name string) (structs.Tag, error) {
+	defer metrics.ObserveRepository("tag", "FindTagByName", time.Now())
+
 	query, err := tr.db.Query(ctx,
 		`SELECT * FROM tags WHERE name = $1`, name)
 	if err != nil {
@@ -61,4 +80,79 @@ func (tr *TagRepository) FindTagByName(ctx context.Context, name string) (struct
 		}
 	}
 	return row, nil
-}
\ No newline at end of file
+}
+
+func (tr *TagRepository) FindTagsByParameters(ctx context.Context, params taghandlers.RequestGetTags) ([]structs.Tag, error) {
+	defer metrics.ObserveRepository("tag", "FindTagsByParameters", time.Now())
+
+	query := "SELECT id, name FROM tags WHERE 1=1"
+	args := []interface{}{}
+
+	if params.Prefix != "" {
+		query += " AND name LIKE $" + strconv.Itoa(len(args)+1)
+		args = append(args, likePrefix(params.Prefix))
+	}
+
+	query += " ORDER BY name, id LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+	args = append(args, params.Limit, params.Offset)
+
+	rows, err := tr.db.Query(ctx, query, args...)
+	if err != nil {
+		tr.log.Error("Failed to query tags", errMsg.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []structs.Tag{}
+	for rows.Next() {
+		var tag structs.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name); err != nil {
+			tr.log.Error("Failed to scan tag row", errMsg.Err(err))
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		tr.log.Error("Error occurred while iterating tag rows", errMsg.Err(err))
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (tr *TagRepository) UpdateTag(ctx context.Context, tag *structs.Tag) error {
+	defer metrics.ObserveRepository("tag", "UpdateTag", time.Now())
+
+	err := tr.db.QueryRow(ctx, `UPDATE tags SET name = $1 WHERE id = $2 RETURNING id`, tag.Name, tag.ID).Scan(&tag.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrTagNotFound
+		}
+		if _, ok := pgError(err, uniqueViolation); ok {
+			return storage.ErrTagExists
+		}
+		tr.log.Error("Failed to update tag", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+// DeleteTag removes the tag. A tag that banners still use is kept and
+// storage.ErrTagInUse is returned.
+func (tr *TagRepository) DeleteTag(ctx context.Context, id int) error {
+	defer metrics.ObserveRepository("tag", "DeleteTag", time.Now())
+
+	result, err := tr.db.Exec(ctx, `DELETE FROM tags WHERE id = $1`, id)
+	if err != nil {
+		if _, ok := pgError(err, foreignKeyViolation); ok {
+			return storage.ErrTagInUse
+		}
+		tr.log.Error("Failed to delete tag", errMsg.Err(err))
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return storage.ErrTagNotFound
+	}
+	return nil
+}
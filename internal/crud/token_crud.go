@@ -0,0 +1,247 @@
+package crud
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/metrics"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TokenRepository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func NewTokenRepository(db *pgxpool.Pool, log *slog.Logger) *TokenRepository {
+	return &TokenRepository{db, log}
+}
+
+func (tr *TokenRepository) CreateRefreshToken(ctx context.Context, token *structs.RefreshToken) error {
+	defer metrics.ObserveRepository("token", "CreateRefreshToken", time.Now())
+
+	err := tr.db.QueryRow(ctx,
+		`INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+		token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt).Scan(&token.ID)
+	if err != nil {
+		tr.log.Error("Failed to create refresh token", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+// RotateRefreshToken marks the token with tokenHash as used and stores next in
+// its family. next gets the user and family of the old token. Presenting a
+// token that was already used revokes the whole family, since either the
+// client or an attacker holds a stolen copy.
+func (tr *TokenRepository) RotateRefreshToken(ctx context.Context, tokenHash string, next *structs.RefreshToken) error {
+	defer metrics.ObserveRepository("token", "RotateRefreshToken", time.Now())
+
+	tx, err := tr.db.Begin(ctx)
+	if err != nil {
+		tr.log.Error("Failed to begin transaction", errMsg.Err(err))
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	var expiresAt time.Time
+	var usedAt, revokedAt *time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT id, user_id, family_id, expires_at, used_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`, tokenHash).
+		Scan(&id, &next.UserID, &next.FamilyID, &expiresAt, &usedAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrRefreshTokenInvalid
+		}
+		tr.log.Error("Failed to find refresh token", errMsg.Err(err))
+		return err
+	}
+
+	if revokedAt != nil || !time.Now().Before(expiresAt) {
+		return storage.ErrRefreshTokenInvalid
+	}
+
+	if usedAt != nil {
+		if err := revokeFamily(ctx, tx, next.FamilyID); err != nil {
+			tr.log.Error("Failed to revoke refresh token family", errMsg.Err(err))
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			tr.log.Error("Failed to commit transaction", errMsg.Err(err))
+			return err
+		}
+		tr.log.Warn("Reused refresh token, family revoked", slog.Int("user_id", next.UserID))
+		return storage.ErrRefreshTokenReused
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = now() WHERE id = $1`, id); err != nil {
+		tr.log.Error("Failed to mark refresh token as used", errMsg.Err(err))
+		return err
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+		next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt).Scan(&next.ID)
+	if err != nil {
+		tr.log.Error("Failed to create refresh token", errMsg.Err(err))
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		tr.log.Error("Failed to commit transaction", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+// RevokeRefreshTokenFamily revokes the token with tokenHash together with
+// every token rotated from the same login.
+func (tr *TokenRepository) RevokeRefreshTokenFamily(ctx context.Context, tokenHash string) error {
+	defer metrics.ObserveRepository("token", "RevokeRefreshTokenFamily", time.Now())
+
+	tx, err := tr.db.Begin(ctx)
+	if err != nil {
+		tr.log.Error("Failed to begin transaction", errMsg.Err(err))
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var familyID string
+	err = tx.QueryRow(ctx, `SELECT family_id FROM refresh_tokens WHERE token_hash = $1`, tokenHash).Scan(&familyID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrRefreshTokenInvalid
+		}
+		tr.log.Error("Failed to find refresh token", errMsg.Err(err))
+		return err
+	}
+
+	if err := revokeFamily(ctx, tx, familyID); err != nil {
+		tr.log.Error("Failed to revoke refresh token family", errMsg.Err(err))
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		tr.log.Error("Failed to commit transaction", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+// RevokeTokenID puts an access token id on the deny-list until expiresAt.
+func (tr *TokenRepository) RevokeTokenID(ctx context.Context, jti string, expiresAt time.Time) error {
+	defer metrics.ObserveRepository("token", "RevokeTokenID", time.Now())
+
+	_, err := tr.db.Exec(ctx,
+		`INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`, jti, expiresAt)
+	if err != nil {
+		tr.log.Error("Failed to revoke token", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+// FindRevokedTokenIDs returns the deny-listed access token ids that have not
+// expired yet, with their expiry.
+func (tr *TokenRepository) FindRevokedTokenIDs(ctx context.Context) (map[string]time.Time, error) {
+	defer metrics.ObserveRepository("token", "FindRevokedTokenIDs", time.Now())
+
+	rows, err := tr.db.Query(ctx, `SELECT jti, expires_at FROM revoked_tokens WHERE expires_at > now()`)
+	if err != nil {
+		tr.log.Error("Failed to query revoked tokens", errMsg.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	revoked := make(map[string]time.Time)
+	for rows.Next() {
+		var jti string
+		var expiresAt time.Time
+		if err := rows.Scan(&jti, &expiresAt); err != nil {
+			tr.log.Error("Failed to scan revoked token", errMsg.Err(err))
+			return nil, err
+		}
+		revoked[jti] = expiresAt
+	}
+
+	if err := rows.Err(); err != nil {
+		tr.log.Error("Error occurred while iterating revoked tokens", errMsg.Err(err))
+		return nil, err
+	}
+	return revoked, nil
+}
+
+// revokeUsername stores the revocation within tx, so it is committed
+// together with the change that caused it.
+func revokeUsername(ctx context.Context, tx pgx.Tx, revocation structs.UserRevocation) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO revoked_users (username, revoked_at, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (username) DO UPDATE SET revoked_at = EXCLUDED.revoked_at,
+			expires_at = GREATEST(revoked_users.expires_at, EXCLUDED.expires_at)`,
+		revocation.Username, revocation.RevokedAt, revocation.ExpiresAt)
+	return err
+}
+
+// FindRevokedUsernames returns the usernames with revoked tokens that may
+// still be unexpired, with the time up to which their tokens are refused.
+func (tr *TokenRepository) FindRevokedUsernames(ctx context.Context) (map[string]time.Time, error) {
+	defer metrics.ObserveRepository("token", "FindRevokedUsernames", time.Now())
+
+	rows, err := tr.db.Query(ctx, `SELECT username, revoked_at FROM revoked_users WHERE expires_at > now()`)
+	if err != nil {
+		tr.log.Error("Failed to query revoked users", errMsg.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	revoked := make(map[string]time.Time)
+	for rows.Next() {
+		var username string
+		var revokedAt time.Time
+		if err := rows.Scan(&username, &revokedAt); err != nil {
+			tr.log.Error("Failed to scan revoked user", errMsg.Err(err))
+			return nil, err
+		}
+		revoked[username] = revokedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		tr.log.Error("Error occurred while iterating revoked users", errMsg.Err(err))
+		return nil, err
+	}
+	return revoked, nil
+}
+
+// DeleteExpiredTokens removes refresh tokens and deny-list entries that can
+// no longer be used.
+func (tr *TokenRepository) DeleteExpiredTokens(ctx context.Context) error {
+	defer metrics.ObserveRepository("token", "DeleteExpiredTokens", time.Now())
+
+	if _, err := tr.db.Exec(ctx, `DELETE FROM refresh_tokens WHERE expires_at <= now()`); err != nil {
+		tr.log.Error("Failed to delete expired refresh tokens", errMsg.Err(err))
+		return err
+	}
+	if _, err := tr.db.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= now()`); err != nil {
+		tr.log.Error("Failed to delete expired revoked tokens", errMsg.Err(err))
+		return err
+	}
+	if _, err := tr.db.Exec(ctx, `DELETE FROM revoked_users WHERE expires_at <= now()`); err != nil {
+		tr.log.Error("Failed to delete expired revoked users", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+func revokeFamily(ctx context.Context, tx pgx.Tx, familyID string) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL`, familyID)
+	return err
+}
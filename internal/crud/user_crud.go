@@ -2,14 +2,22 @@ package crud
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	userhandlers "banner-serivce/internal/handlers/user_handlers"
+	"banner-serivce/internal/metrics"
+	"banner-serivce/internal/storage"
 	"banner-serivce/internal/structs"
 	"context"
-	"fmt"
+	"errors"
 	"log/slog"
+	"strconv"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const userColumns = "id, username, password, role, disabled"
+
 type UserRepository struct {
 	db  *pgxpool.Pool
 	log *slog.Logger
@@ -20,6 +28,8 @@ func NewUserRepository(db *pgxpool.Pool, log *slog.Logger) *UserRepository {
 }
 
 func (u *UserRepository) CreateUser(ctx context.Context, user *structs.User) error {
+	defer metrics.ObserveRepository("user", "CreateUser", time.Now())
+
 	err := u.db.QueryRow(ctx, `INSERT INTO users (username, password, role) VALUES ($1, $2, $3) RETURNING id`, user.Username, user.Password, user.Role).Scan(&user.ID)
 	if err != nil {
 		u.log.Error("Failed to create user", errMsg.Err(err))
@@ -29,45 +39,156 @@ func (u *UserRepository) CreateUser(ctx context.Context, user *structs.User) err
 }
 
 func (u *UserRepository) FindUserByName(ctx context.Context, username string) (structs.User, error) {
-	query, err := u.db.Query(ctx, `SELECT * FROM users WHERE username = $1`, username)
+	defer metrics.ObserveRepository("user", "FindUserByName", time.Now())
+
+	return u.findUser(ctx, `SELECT `+userColumns+` FROM users WHERE username = $1`, username)
+}
+
+func (ur *UserRepository) FindUserById(ctx context.Context, id int) (structs.User, error) {
+	defer metrics.ObserveRepository("user", "FindUserById", time.Now())
+
+	return ur.findUser(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id)
+}
+
+func (u *UserRepository) findUser(ctx context.Context, query string, args ...interface{}) (structs.User, error) {
+	var user structs.User
+	err := u.db.QueryRow(ctx, query, args...).
+		Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.Disabled)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return structs.User{}, storage.ErrUserNotFound
+		}
 		u.log.Error("Error querying users", errMsg.Err(err))
 		return structs.User{}, err
 	}
-	row := structs.User{}
-	defer query.Close()
-	if !query.Next() {
-		u.log.Error("User not found")
-		return structs.User{}, fmt.Errorf("User not found")
-	} else {
-		err := query.Scan(&row.ID, &row.Username, &row.Password, &row.Role)
-		if err != nil {
-			u.log.Error("Error scanning users", errMsg.Err(err))
-			return structs.User{}, err
+	return user, nil
+}
+
+func (u *UserRepository) FindUsers(ctx context.Context, params userhandlers.RequestGetUsers) ([]structs.User, error) {
+	defer metrics.ObserveRepository("user", "FindUsers", time.Now())
+
+	query := "SELECT " + userColumns + " FROM users WHERE 1=1"
+	args := []interface{}{}
+
+	if params.Role != "" {
+		query += " AND role = $" + strconv.Itoa(len(args)+1)
+		args = append(args, params.Role)
+	}
+	if params.Disabled != nil {
+		query += " AND disabled = $" + strconv.Itoa(len(args)+1)
+		args = append(args, *params.Disabled)
+	}
+
+	query += " ORDER BY id LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+	args = append(args, params.Limit, params.Offset)
+
+	rows, err := u.db.Query(ctx, query, args...)
+	if err != nil {
+		u.log.Error("Failed to query users", errMsg.Err(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []structs.User{}
+	for rows.Next() {
+		var user structs.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.Disabled); err != nil {
+			u.log.Error("Failed to scan user row", errMsg.Err(err))
+			return nil, err
 		}
+		users = append(users, user)
 	}
-	return row, nil
+
+	if err := rows.Err(); err != nil {
+		u.log.Error("Error occurred while iterating user rows", errMsg.Err(err))
+		return nil, err
+	}
+
+	return users, nil
 }
 
-func (ur *UserRepository) FindUserById(ctx context.Context, id int) (structs.User, error) {
-	query, err := ur.db.Query(ctx,
-		`SELECT * FROM users WHERE id = $1`, id)
+// UpdateUser stores the role and disabled flag of the user. Disabling the
+// user also revokes its refresh tokens, so re-enabling it later does not
+// bring old sessions back. A non-nil revocation of the user's access tokens
+// is stored in the same transaction.
+func (u *UserRepository) UpdateUser(ctx context.Context, user *structs.User, revocation *structs.UserRevocation) error {
+	defer metrics.ObserveRepository("user", "UpdateUser", time.Now())
+
+	tx, err := u.db.Begin(ctx)
 	if err != nil {
-		ur.log.Error("Error querying users", errMsg.Err(err))
-		return structs.User{}, err
+		u.log.Error("Failed to begin transaction", errMsg.Err(err))
+		return err
 	}
-	defer query.Close()
-	rowArray := structs.User{}
-	if !query.Next() {
-		ur.log.Error("User not found")
-		return structs.User{}, fmt.Errorf("User not found")
+	defer tx.Rollback(ctx)
 
-	} else {
-		err := query.Scan(&rowArray.ID, &rowArray.Username, &rowArray.Password, &rowArray.Role)
+	err = tx.QueryRow(ctx,
+		`UPDATE users SET role = $1, disabled = $2 WHERE id = $3 RETURNING username`,
+		user.Role, user.Disabled, user.ID).Scan(&user.Username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrUserNotFound
+		}
+		u.log.Error("Failed to update user", errMsg.Err(err))
+		return err
+	}
+
+	if user.Disabled {
+		_, err := tx.Exec(ctx,
+			`UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`, user.ID)
 		if err != nil {
-			ur.log.Error("Error scanning users", errMsg.Err(err))
-			return structs.User{}, err
+			u.log.Error("Failed to revoke refresh tokens", errMsg.Err(err))
+			return err
 		}
 	}
-	return rowArray, nil
+
+	if revocation != nil {
+		revocation.Username = user.Username
+		if err := revokeUsername(ctx, tx, *revocation); err != nil {
+			u.log.Error("Failed to revoke user tokens", errMsg.Err(err))
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		u.log.Error("Failed to commit transaction", errMsg.Err(err))
+		return err
+	}
+	return nil
+}
+
+// DeleteUser removes the user together with its refresh tokens. A non-nil
+// revocation of the user's access tokens is stored in the same transaction.
+func (u *UserRepository) DeleteUser(ctx context.Context, id int, revocation *structs.UserRevocation) error {
+	defer metrics.ObserveRepository("user", "DeleteUser", time.Now())
+
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		u.log.Error("Failed to begin transaction", errMsg.Err(err))
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var username string
+	err = tx.QueryRow(ctx, `DELETE FROM users WHERE id = $1 RETURNING username`, id).Scan(&username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrUserNotFound
+		}
+		u.log.Error("Failed to delete user", errMsg.Err(err))
+		return err
+	}
+
+	if revocation != nil {
+		revocation.Username = username
+		if err := revokeUsername(ctx, tx, *revocation); err != nil {
+			u.log.Error("Failed to revoke user tokens", errMsg.Err(err))
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		u.log.Error("Failed to commit transaction", errMsg.Err(err))
+		return err
+	}
+	return nil
 }
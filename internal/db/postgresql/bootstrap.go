@@ -0,0 +1,61 @@
+package postgresql
+
+import (
+	"banner-serivce/internal/auth"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BootstrapAdmin creates the configured admin account unless an admin already
+// exists. When requirePassword is set an empty password is an error, even if
+// there is nothing to create.
+func (pg *Postgres) BootstrapAdmin(ctx context.Context, requirePassword bool) error {
+	admin := pg.Config.DefaultAdmin
+	log := pg.log.With(slog.String("username", admin.Username))
+
+	if admin.Password == "" && requirePassword {
+		return errors.New("default admin password is not set")
+	}
+
+	var exists bool
+	err := pg.Db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE role = $1)`, auth.RoleAdmin).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check for admin: %w", err)
+	}
+	if exists {
+		log.Info("admin already exists, skipping bootstrap")
+		return nil
+	}
+
+	if admin.Password == "" {
+		log.Warn("no admin exists and default admin password is not set, skipping bootstrap")
+		return nil
+	}
+
+	hashPass, err := auth.HashPassword(admin.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash admin password: %w", err)
+	}
+
+	var id int
+	err = pg.Db.QueryRow(ctx,
+		`INSERT INTO users (username, password, role)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (SELECT 1 FROM users WHERE role = $3)
+		ON CONFLICT (username) DO NOTHING
+		RETURNING id`, admin.Username, hashPass, auth.RoleAdmin).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Warn("default admin was not created: username is taken or another instance created an admin first")
+			return nil
+		}
+		return fmt.Errorf("failed to create admin: %w", err)
+	}
+
+	log.Info("default admin created", slog.Int("user_id", id))
+	return nil
+}
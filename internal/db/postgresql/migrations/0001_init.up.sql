@@ -0,0 +1,35 @@
+-- Databases created before migrations were introduced already have these
+-- tables, so the early migrations are written to be re-runnable.
+CREATE TABLE IF NOT EXISTS banners (
+	id SERIAL PRIMARY KEY,
+	feature_id INTEGER,
+	content JSONB,
+	is_active BOOLEAN,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id SERIAL PRIMARY KEY,
+	name TEXT
+);
+
+CREATE TABLE IF NOT EXISTS banner_tags (
+	banner_id INTEGER,
+	tag_id INTEGER,
+	PRIMARY KEY (banner_id, tag_id),
+	FOREIGN KEY (banner_id) REFERENCES banners(id) ON DELETE CASCADE,
+	FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS features (
+	id SERIAL PRIMARY KEY,
+	name TEXT
+);
+
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	username TEXT UNIQUE,
+	password TEXT,
+	role TEXT
+);
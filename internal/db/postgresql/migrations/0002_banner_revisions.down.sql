@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS banner_revisions;
+ALTER TABLE banners DROP COLUMN IF EXISTS version;
@@ -0,0 +1,13 @@
+ALTER TABLE banners ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS banner_revisions (
+	banner_id INTEGER,
+	version INTEGER,
+	feature_id INTEGER,
+	tag_ids INTEGER[],
+	content JSONB,
+	is_active BOOLEAN,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (banner_id, version),
+	FOREIGN KEY (banner_id) REFERENCES banners(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS banner_tags_feature_id_tag_id_key;
+ALTER TABLE banner_tags DROP CONSTRAINT IF EXISTS banner_tags_banner_feature_fkey;
+ALTER TABLE banner_tags DROP COLUMN IF EXISTS feature_id;
+DROP INDEX IF EXISTS banners_id_feature_id_key;
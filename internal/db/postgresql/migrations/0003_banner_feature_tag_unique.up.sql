@@ -0,0 +1,38 @@
+-- banner_tags carries a copy of the banner's feature_id so that a unique
+-- index can stop two banners from owning the same feature and tag pair.
+CREATE UNIQUE INDEX IF NOT EXISTS banners_id_feature_id_key ON banners (id, feature_id);
+
+ALTER TABLE banner_tags ADD COLUMN IF NOT EXISTS feature_id INTEGER;
+
+UPDATE banner_tags bt SET feature_id = b.feature_id
+	FROM banners b
+	WHERE b.id = bt.banner_id AND bt.feature_id IS DISTINCT FROM b.feature_id;
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'banner_tags_banner_feature_fkey') THEN
+		ALTER TABLE banner_tags ADD CONSTRAINT banner_tags_banner_feature_fkey
+			FOREIGN KEY (banner_id, feature_id) REFERENCES banners(id, feature_id)
+			ON UPDATE CASCADE ON DELETE CASCADE;
+	END IF;
+END $$;
+
+-- Banners that already share a pair must be resolved by an operator, by
+-- moving them to other tags or deleting them; the migration lists them
+-- instead of choosing which banner keeps its link.
+DO $$
+DECLARE
+	conflicts TEXT;
+BEGIN
+	SELECT string_agg(format('(banner_id %s, feature_id %s, tag_id %s)', bt.banner_id, bt.feature_id, bt.tag_id), ', '
+			ORDER BY bt.feature_id, bt.tag_id, bt.banner_id)
+		INTO conflicts
+		FROM banner_tags bt
+		WHERE EXISTS (SELECT 1 FROM banner_tags other
+			WHERE other.feature_id = bt.feature_id AND other.tag_id = bt.tag_id AND other.banner_id <> bt.banner_id);
+	IF conflicts IS NOT NULL THEN
+		RAISE EXCEPTION 'banners share feature and tag pairs, move them to other tags or delete them first: %', conflicts;
+	END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS banner_tags_feature_id_tag_id_key ON banner_tags (feature_id, tag_id);
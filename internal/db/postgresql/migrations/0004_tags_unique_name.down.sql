@@ -0,0 +1,5 @@
+ALTER TABLE banner_tags DROP CONSTRAINT banner_tags_tag_id_fkey;
+ALTER TABLE banner_tags ADD CONSTRAINT banner_tags_tag_id_fkey
+	FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE;
+
+DROP INDEX tags_name_key;
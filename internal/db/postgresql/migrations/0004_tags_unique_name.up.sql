@@ -0,0 +1,23 @@
+-- Tag names were not unique before this migration. A tag sharing its name
+-- with a tag of a lower id is renamed to "<name> (<id>)", so the index can be
+-- built without touching any banner. Each rename is reported as a notice.
+DO $$
+DECLARE
+	renamed RECORD;
+BEGIN
+	FOR renamed IN
+		UPDATE tags t SET name = t.name || ' (' || t.id || ')'
+			WHERE EXISTS (SELECT 1 FROM tags older WHERE older.name = t.name AND older.id < t.id)
+			RETURNING t.id, t.name
+	LOOP
+		RAISE NOTICE 'tag % renamed to % to make tag names unique', renamed.id, renamed.name;
+	END LOOP;
+END $$;
+
+CREATE UNIQUE INDEX tags_name_key ON tags (name);
+
+-- Tags still used by banners can only be removed after their banner_tags
+-- rows are deleted explicitly.
+ALTER TABLE banner_tags DROP CONSTRAINT banner_tags_tag_id_fkey;
+ALTER TABLE banner_tags ADD CONSTRAINT banner_tags_tag_id_fkey
+	FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE RESTRICT;
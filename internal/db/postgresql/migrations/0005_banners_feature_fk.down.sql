@@ -0,0 +1,2 @@
+ALTER TABLE banners DROP CONSTRAINT banners_feature_id_fkey;
+ALTER TABLE banners ALTER COLUMN feature_id DROP NOT NULL;
@@ -0,0 +1,38 @@
+-- Banners could reference features that no longer exist. Such a feature is
+-- recreated as a placeholder named "feature <id>", so its banners keep being
+-- served and can be reassigned later. Each placeholder is reported as a
+-- notice.
+DO $$
+DECLARE
+	orphan RECORD;
+BEGIN
+	FOR orphan IN
+		INSERT INTO features (id, name)
+			SELECT DISTINCT b.feature_id, 'feature ' || b.feature_id
+			FROM banners b
+			WHERE b.feature_id IS NOT NULL
+				AND NOT EXISTS (SELECT 1 FROM features f WHERE f.id = b.feature_id)
+			RETURNING id
+	LOOP
+		RAISE NOTICE 'placeholder feature % created for its banners', orphan.id;
+	END LOOP;
+END $$;
+
+-- Banners without a feature can never be served. The migration lists them
+-- instead of deleting them, so an operator can assign them a feature or
+-- delete them first.
+DO $$
+DECLARE
+	orphans TEXT;
+BEGIN
+	SELECT string_agg(id::TEXT, ', ' ORDER BY id) INTO orphans FROM banners WHERE feature_id IS NULL;
+	IF orphans IS NOT NULL THEN
+		RAISE EXCEPTION 'banners have no feature, assign them one or delete them first: %', orphans;
+	END IF;
+END $$;
+
+SELECT setval(pg_get_serial_sequence('features', 'id'), GREATEST((SELECT MAX(id) FROM features), 1));
+
+ALTER TABLE banners ALTER COLUMN feature_id SET NOT NULL;
+ALTER TABLE banners ADD CONSTRAINT banners_feature_id_fkey
+	FOREIGN KEY (feature_id) REFERENCES features(id) ON DELETE RESTRICT;
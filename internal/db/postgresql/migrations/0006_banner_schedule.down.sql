@@ -0,0 +1,6 @@
+ALTER TABLE banner_revisions DROP COLUMN active_until;
+ALTER TABLE banner_revisions DROP COLUMN active_from;
+
+ALTER TABLE banners DROP CONSTRAINT banners_active_window_check;
+ALTER TABLE banners DROP COLUMN active_until;
+ALTER TABLE banners DROP COLUMN active_from;
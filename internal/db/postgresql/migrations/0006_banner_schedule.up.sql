@@ -0,0 +1,7 @@
+ALTER TABLE banners ADD COLUMN active_from TIMESTAMPTZ;
+ALTER TABLE banners ADD COLUMN active_until TIMESTAMPTZ;
+ALTER TABLE banners ADD CONSTRAINT banners_active_window_check
+	CHECK (active_from IS NULL OR active_until IS NULL OR active_from < active_until);
+
+ALTER TABLE banner_revisions ADD COLUMN active_from TIMESTAMPTZ;
+ALTER TABLE banner_revisions ADD COLUMN active_until TIMESTAMPTZ;
@@ -0,0 +1,9 @@
+CREATE TABLE banner_variants (
+	id SERIAL PRIMARY KEY,
+	banner_id INTEGER NOT NULL REFERENCES banners(id) ON DELETE CASCADE,
+	content JSONB NOT NULL,
+	weight INTEGER NOT NULL CHECK (weight > 0),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX banner_variants_banner_id_idx ON banner_variants (banner_id);
@@ -0,0 +1,31 @@
+CREATE TABLE audit_events (
+	id BIGSERIAL PRIMARY KEY,
+	occurred_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	actor TEXT NOT NULL,
+	action TEXT NOT NULL,
+	entity_type TEXT NOT NULL,
+	entity_id INTEGER,
+	before JSONB,
+	after JSONB,
+	request_id TEXT
+);
+
+CREATE INDEX audit_events_entity_idx ON audit_events (entity_type, entity_id, occurred_at);
+CREATE INDEX audit_events_actor_idx ON audit_events (actor, occurred_at);
+CREATE INDEX audit_events_occurred_at_idx ON audit_events (occurred_at);
+
+-- The audit log is append-only: rows can be inserted but never changed or
+-- removed, not even by the service itself.
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update_delete
+	BEFORE UPDATE OR DELETE ON audit_events
+	FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+	BEFORE TRUNCATE ON audit_events
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,2 @@
+DROP TABLE revoked_users;
+ALTER TABLE users DROP COLUMN disabled;
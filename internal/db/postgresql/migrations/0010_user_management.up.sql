@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT false;
+
+-- revoked_users refuses every access token issued to a username up to
+-- revoked_at. It is written when a user is disabled, changes role or is
+-- deleted, and rows are kept until tokens issued before revoked_at expire.
+CREATE TABLE revoked_users (
+	username TEXT PRIMARY KEY,
+	revoked_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);
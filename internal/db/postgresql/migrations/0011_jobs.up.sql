@@ -0,0 +1,18 @@
+-- Background jobs are kept here so any replica can report on them and queued
+-- jobs survive a restart. The worker running a job refreshes heartbeat_at;
+-- a running job with an old heartbeat was abandoned and is claimed again.
+CREATE TABLE jobs (
+	id TEXT PRIMARY KEY,
+	kind TEXT NOT NULL,
+	params JSONB NOT NULL,
+	status TEXT NOT NULL,
+	total INTEGER NOT NULL DEFAULT 0,
+	done INTEGER NOT NULL DEFAULT 0,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	error TEXT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	heartbeat_at TIMESTAMPTZ,
+	finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX jobs_status_created_at_idx ON jobs (status, created_at);
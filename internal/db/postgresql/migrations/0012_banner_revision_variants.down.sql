@@ -0,0 +1 @@
+ALTER TABLE banner_revisions DROP COLUMN variants;
@@ -0,0 +1,3 @@
+-- Revisions written before this migration did not record the variants; their
+-- variants stay NULL and restoring them keeps the current variants.
+ALTER TABLE banner_revisions ADD COLUMN variants JSONB;
@@ -1,7 +1,6 @@
 package postgresql
 
 import (
-	"banner-serivce/internal/auth"
 	"banner-serivce/internal/config"
 	"context"
 	"fmt"
@@ -33,9 +32,6 @@ func NewPG(ctx context.Context, connString string, log *slog.Logger, cfg *config
 		}
 
 		pgInstance = &Postgres{db, log, cfg}
-		if err = CreateTables(ctx, db, log, cfg); err != nil {
-			return
-		}
 	})
 
 	if err != nil {
@@ -44,69 +40,13 @@ func NewPG(ctx context.Context, connString string, log *slog.Logger, cfg *config
 	return pgInstance, nil
 }
 
-func CreateTables(ctx context.Context, db *pgxpool.Pool, log *slog.Logger, cfg *config.Config) error {
-	_, err := db.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS banners (
-			id SERIAL PRIMARY KEY,
-			feature_id INTEGER,
-			content JSONB,
-			is_active BOOLEAN,
-			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create banners table: %w", err)
-	}
-
-	_, err = db.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS tags (
-			id SERIAL PRIMARY KEY,
-			name TEXT
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create tags table: %w", err)
-	}
-
-	_, err = db.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS banner_tags (
-			banner_id INTEGER,
-			tag_id INTEGER,
-			PRIMARY KEY (banner_id, tag_id),
-			FOREIGN KEY (banner_id) REFERENCES banners(id) ON DELETE CASCADE,
-			FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create banner_tags table: %w", err)
-	}
-
-	_, err = db.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS features (
-			id SERIAL PRIMARY KEY,
-			name TEXT
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create features table: %w", err)
-	}
-
-	_, err = db.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS users (
-		    id SERIAL PRIMARY KEY ,
-			username TEXT UNIQUE,
-			password TEXT,
-			role TEXT
-		)
-	`)
+// Migrate brings the schema up to date with the embedded migrations.
+func (pg *Postgres) Migrate(ctx context.Context) error {
+	migrator, err := NewMigrator(pg.Db, pg.log)
 	if err != nil {
-		return fmt.Errorf("failed to create users table: %w", err)
+		return err
 	}
-	hashPass, err := auth.HashPassword(cfg.DefaultAdminPass)
-	_, err = db.Exec(ctx, `INSERT INTO users (username, password, role) VALUES ($1,$2,$3)`, "admin", hashPass, "admin")
-	log.Info("Tables created (or updated)")
-	return nil
+	return migrator.Up(ctx)
 }
 
 func (pg *Postgres) Ping(ctx context.Context) error {
@@ -0,0 +1,96 @@
+package audithandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/paging"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/structs"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type AuditEvents interface {
+	FindAuditEvents(ctx context.Context, params RequestGetAuditEvents) ([]structs.AuditEvent, error)
+}
+
+// RequestGetAuditEvents filters the audit log. Empty fields do not filter;
+// From is inclusive and To is exclusive.
+type RequestGetAuditEvents struct {
+	EntityType string     `json:"entity_type"`
+	EntityID   *int       `json:"entity_id"`
+	Actor      string     `json:"actor"`
+	From       *time.Time `json:"from"`
+	To         *time.Time `json:"to"`
+	paging.Page
+}
+
+// NewGetAuditEventsHandler lists audit events, newest first.
+func NewGetAuditEventsHandler(log *slog.Logger, auditRepository AuditEvents) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.audit.getAuditEvents.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		req, err := parseGetAuditEventsRequest(r)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		events, err := auditRepository.FindAuditEvents(r.Context(), req)
+		if err != nil {
+			log.Error("Failed to get audit events", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get audit events"))
+			return
+		}
+
+		render.JSON(w, r, events)
+	}
+}
+
+func parseGetAuditEventsRequest(r *http.Request) (RequestGetAuditEvents, error) {
+	query := r.URL.Query()
+	req := RequestGetAuditEvents{
+		EntityType: query.Get("entity_type"),
+		Actor:      query.Get("actor"),
+	}
+
+	if entityIDStr := query.Get("entity_id"); entityIDStr != "" {
+		entityID, err := strconv.Atoi(entityIDStr)
+		if err != nil {
+			return RequestGetAuditEvents{}, errors.New("invalid entity_id")
+		}
+		req.EntityID = &entityID
+	}
+
+	for name, target := range map[string]**time.Time{"from": &req.From, "to": &req.To} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return RequestGetAuditEvents{}, errors.New("invalid " + name + ", use RFC 3339 with a time zone offset")
+			}
+			*target = &t
+		}
+	}
+
+	page, err := paging.Parse(query, defaultAuditLimit, maxAuditLimit)
+	if err != nil {
+		return RequestGetAuditEvents{}, err
+	}
+	req.Page = page
+
+	return req, nil
+}
@@ -0,0 +1,146 @@
+package bannerhandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/auth"
+	"banner-serivce/internal/auth/jwt"
+	"banner-serivce/internal/structs"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// maxBatchBanners caps the number of banners asked for in one batch request.
+const maxBatchBanners = 100
+
+// RequestBatchBanners asks for several banners at once, either as a list of
+// feature and tag pairs in Items or as one TagID with many FeatureIDs.
+type RequestBatchBanners struct {
+	Items           []structs.FeatureTag `json:"items"`
+	TagID           *int                 `json:"tag_id"`
+	FeatureIDs      []int                `json:"feature_ids"`
+	UseLastRevision bool                 `json:"use_last_revision"`
+	UserID          string               `json:"user_id"`
+}
+
+// BatchBannerResult is the outcome for one pair of a batch. Error is set
+// instead of Content when the banner cannot be shown.
+type BatchBannerResult struct {
+	FeatureID int                    `json:"feature_id"`
+	TagID     int                    `json:"tag_id"`
+	Content   map[string]interface{} `json:"content,omitempty"`
+	VariantID *int                   `json:"variant_id,omitempty"`
+	Inactive  bool                   `json:"inactive,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// ResponseBatchBanners maps "<feature_id>:<tag_id>" to the result for that
+// pair.
+type ResponseBatchBanners struct {
+	Banners map[string]BatchBannerResult `json:"banners"`
+}
+
+// NewGetBannersBatchHandler serves several banners in one round trip, under
+// the same rules as NewGetBannerHandler: inactive or unscheduled banners are
+// reported as not found unless the caller is an admin, and A/B variants are
+// picked by user_id or the username in the token.
+func NewGetBannersBatchHandler(log *slog.Logger, bannerCache BannerCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.banners.userBannerBatch.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		var req RequestBatchBanners
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("Failed to decode request body", errMsg.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Failed to decode request"))
+			return
+		}
+
+		pairs, err := batchPairs(req)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		banners, err := bannerCache.GetBanners(r.Context(), pairs, req.UseLastRevision)
+		if err != nil {
+			log.Error("Failed to find banners", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to find banners"))
+			return
+		}
+
+		principal, _ := jwt.PrincipalFromContext(r.Context())
+		subject := req.UserID
+		if subject == "" {
+			subject = principal.Username
+		}
+
+		now := time.Now()
+		results := make(map[string]BatchBannerResult, len(pairs))
+		for _, pair := range pairs {
+			result := BatchBannerResult{FeatureID: pair.FeatureID, TagID: pair.TagID}
+			banner, ok := banners[pair]
+			switch {
+			case !ok:
+				result.Error = "Banner not found"
+			case !banner.IsLive(now) && principal.Role != auth.RoleAdmin:
+				result.Error = "Banner not found"
+			default:
+				result.Inactive = !banner.IsLive(now)
+				result.Content = banner.Content
+				if variant := chooseVariant(banner, subject); variant != nil {
+					result.Content = variant.Content
+					result.VariantID = &variant.ID
+				}
+			}
+			results[strconv.Itoa(pair.FeatureID)+":"+strconv.Itoa(pair.TagID)] = result
+		}
+
+		render.JSON(w, r, ResponseBatchBanners{Banners: results})
+	}
+}
+
+// batchPairs turns either form of the request into a list of distinct pairs.
+func batchPairs(req RequestBatchBanners) ([]structs.FeatureTag, error) {
+	var pairs []structs.FeatureTag
+	switch {
+	case len(req.Items) > 0 && (req.TagID != nil || len(req.FeatureIDs) > 0):
+		return nil, errors.New("use either items or tag_id with feature_ids")
+	case len(req.Items) > 0:
+		pairs = req.Items
+	case req.TagID != nil && len(req.FeatureIDs) > 0:
+		for _, featureID := range req.FeatureIDs {
+			pairs = append(pairs, structs.FeatureTag{FeatureID: featureID, TagID: *req.TagID})
+		}
+	default:
+		return nil, errors.New("items or tag_id with feature_ids is required")
+	}
+
+	seen := make(map[structs.FeatureTag]bool, len(pairs))
+	distinct := make([]structs.FeatureTag, 0, len(pairs))
+	for _, pair := range pairs {
+		if pair.FeatureID <= 0 || pair.TagID <= 0 {
+			return nil, errors.New("feature_id and tag_id must be positive")
+		}
+		if seen[pair] {
+			continue
+		}
+		seen[pair] = true
+		distinct = append(distinct, pair)
+	}
+
+	if len(distinct) > maxBatchBanners {
+		return nil, errors.New("too many banners requested, the limit is " + strconv.Itoa(maxBatchBanners))
+	}
+	return distinct, nil
+}
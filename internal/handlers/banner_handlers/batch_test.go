@@ -0,0 +1,82 @@
+package bannerhandlers
+
+import (
+	"banner-serivce/internal/structs"
+	"slices"
+	"testing"
+)
+
+func TestBatchPairs(t *testing.T) {
+	tagID := 5
+	tooMany := make([]int, maxBatchBanners+1)
+	sameFeature := make([]int, maxBatchBanners+1)
+	for i := range tooMany {
+		tooMany[i] = i + 1
+		sameFeature[i] = 1
+	}
+
+	tests := []struct {
+		name    string
+		req     RequestBatchBanners
+		want    []structs.FeatureTag
+		wantErr bool
+	}{
+		{
+			name: "items",
+			req:  RequestBatchBanners{Items: []structs.FeatureTag{{FeatureID: 1, TagID: 2}, {FeatureID: 3, TagID: 4}}},
+			want: []structs.FeatureTag{{FeatureID: 1, TagID: 2}, {FeatureID: 3, TagID: 4}},
+		},
+		{
+			name: "tag with features",
+			req:  RequestBatchBanners{TagID: &tagID, FeatureIDs: []int{1, 2}},
+			want: []structs.FeatureTag{{FeatureID: 1, TagID: 5}, {FeatureID: 2, TagID: 5}},
+		},
+		{
+			name: "duplicates dropped in order",
+			req:  RequestBatchBanners{Items: []structs.FeatureTag{{FeatureID: 1, TagID: 2}, {FeatureID: 3, TagID: 4}, {FeatureID: 1, TagID: 2}}},
+			want: []structs.FeatureTag{{FeatureID: 1, TagID: 2}, {FeatureID: 3, TagID: 4}},
+		},
+		{
+			name:    "both forms",
+			req:     RequestBatchBanners{Items: []structs.FeatureTag{{FeatureID: 1, TagID: 2}}, TagID: &tagID},
+			wantErr: true,
+		},
+		{
+			name:    "neither form",
+			req:     RequestBatchBanners{},
+			wantErr: true,
+		},
+		{
+			name:    "features without tag",
+			req:     RequestBatchBanners{FeatureIDs: []int{1}},
+			wantErr: true,
+		},
+		{
+			name:    "non-positive id",
+			req:     RequestBatchBanners{Items: []structs.FeatureTag{{FeatureID: 0, TagID: 2}}},
+			wantErr: true,
+		},
+		{
+			name:    "too many",
+			req:     RequestBatchBanners{TagID: &tagID, FeatureIDs: tooMany},
+			wantErr: true,
+		},
+		{
+			name: "limit after dropping duplicates",
+			req:  RequestBatchBanners{TagID: &tagID, FeatureIDs: sameFeature},
+			want: []structs.FeatureTag{{FeatureID: 1, TagID: 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := batchPairs(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("batchPairs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("batchPairs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,15 +2,18 @@ package bannerhandlers
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
 	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/audit"
+	"banner-serivce/internal/storage"
 	"banner-serivce/internal/structs"
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"time"
 
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 )
@@ -18,30 +21,54 @@ import (
 type Banners interface {
 	CreateBanner(ctx context.Context, banner *structs.Banner) error
 	FindBannerByFeatureTag(ctx context.Context, featureID, tagID int) (*structs.Banner, error)
-	DeleteBannerByID(ctx context.Context, id int) error
+	DeleteBannerByID(ctx context.Context, id int, ifVersions []int) error
+	FindBannerIDs(ctx context.Context, featureID, tagID *int) ([]int, error)
 	FindBannersByParameters(ctx context.Context, params RequestGetBanners) ([]structs.Banner, error)
-	UpdateBanner(ctx context.Context, banner *structs.Banner) error
+	UpdateBanner(ctx context.Context, banner *structs.Banner, ifVersions []int) error
 	FindBannerByID(ctx context.Context, id int) (structs.Banner, error)
+	FindBannerRevisions(ctx context.Context, bannerID int) ([]structs.BannerRevision, error)
+	ActivateBannerRevision(ctx context.Context, bannerID, version int, ifVersions []int) (structs.Banner, error)
+	FindBannerVariants(ctx context.Context, bannerID int) ([]structs.BannerVariant, error)
+	ReplaceBannerVariants(ctx context.Context, bannerID int, variants []structs.BannerVariant) ([]structs.BannerVariant, error)
 }
 
-type BannerTags interface {
-	CreateBannerTag(ctx context.Context, bannerTag *structs.BannerTag) error
+// Auditor records administrative changes, see audit.Recorder.
+type Auditor interface {
+	Record(ctx context.Context, event audit.Event)
 }
 
 type RequestBanner struct {
 	TagIDs    []int                  `json:"tag_ids" validate:"required"`
 	FeatureID int                    `json:"feature_id" validate:"required"`
 	Content   map[string]interface{} `json:"content" validate:"required"`
-	IsActive  bool                   `json:"is_active" validate:"required"`
+	IsActive  bool                   `json:"is_active"`
+	// ActiveFrom and ActiveUntil are RFC 3339 timestamps and must carry a
+	// time zone offset, e.g. 2024-05-01T00:00:00+03:00.
+	ActiveFrom  *time.Time `json:"active_from"`
+	ActiveUntil *time.Time `json:"active_until"`
 }
 
 type ResponseBanner struct {
 	response.Response
-	ID        int                    `json:"banner_id"`
-	TagIDs    []int                  `json:"tag_ids"`
-	FeatureID int                    `json:"feature_id"`
-	Content   map[string]interface{} `json:"content"`
-	IsActive  bool                   `json:"is_active"`
+	ID          int                    `json:"banner_id"`
+	TagIDs      []int                  `json:"tag_ids"`
+	FeatureID   int                    `json:"feature_id"`
+	Content     map[string]interface{} `json:"content"`
+	IsActive    bool                   `json:"is_active"`
+	ActiveFrom  *time.Time             `json:"active_from"`
+	ActiveUntil *time.Time             `json:"active_until"`
+	Version     int                    `json:"version"`
+}
+
+type ResponseConflict struct {
+	response.Response
+	BannerID int `json:"banner_id"`
+}
+
+type ResponseUnknownReferences struct {
+	response.Response
+	UnknownFeatureID *int  `json:"unknown_feature_id,omitempty"`
+	UnknownTagIDs    []int `json:"unknown_tag_ids,omitempty"`
 }
 
 type Response struct {
@@ -49,12 +76,10 @@ type Response struct {
 	Error  string `json:"error,omitempty"`
 }
 
-func New(log *slog.Logger, bannerRepository Banners, bannerTagsRepository BannerTags) http.HandlerFunc {
+func New(log *slog.Logger, bannerRepository Banners, auditor Auditor) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const loggerOptions = "handlers.banners.CreateBanner.New"
-		log = log.With(
-			slog.String("options", loggerOptions),
-			slog.String("request_id", middleware.GetReqID(r.Context())))
+		log := requestlog.New(log, r, loggerOptions)
 
 		var req RequestBanner
 		err := json.NewDecoder(r.Body).Decode(&req)
@@ -73,16 +98,35 @@ func New(log *slog.Logger, bannerRepository Banners, bannerTagsRepository Banner
 		}
 
 		banner := structs.Banner{
-			TagIDs:    req.TagIDs,
-			FeatureID: req.FeatureID,
-			Content:   req.Content,
-			IsActive:  req.IsActive,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			TagIDs:      req.TagIDs,
+			FeatureID:   req.FeatureID,
+			Content:     req.Content,
+			IsActive:    req.IsActive,
+			ActiveFrom:  structs.InUTC(req.ActiveFrom),
+			ActiveUntil: structs.InUTC(req.ActiveUntil),
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+		if err := validateSchedule(banner); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
 		}
 
 		err = bannerRepository.CreateBanner(r.Context(), &banner)
 		if err != nil {
+			var conflict *storage.BannerConflictError
+			if errors.As(err, &conflict) {
+				log.Info("Feature and tag pair already taken", slog.Int("banner_id", conflict.BannerID))
+				responseConflict(w, r, conflict)
+				return
+			}
+			var unknown *storage.UnknownReferencesError
+			if errors.As(err, &unknown) {
+				log.Info("Banner references unknown ids", errMsg.Err(err))
+				responseUnknownReferences(w, r, unknown)
+				return
+			}
 			log.Error("Failed to create banner", errMsg.Err(err))
 			render.Status(r, http.StatusBadRequest)
 			render.JSON(w, r, response.Error("Failed to create banner"))
@@ -90,31 +134,44 @@ func New(log *slog.Logger, bannerRepository Banners, bannerTagsRepository Banner
 		}
 
 		log.Info("banner added")
-		for _, tagID := range req.TagIDs {
-			bannerTag := structs.BannerTag{
-				BannerID: banner.ID,
-				TagID:    tagID,
-			}
-			err = bannerTagsRepository.CreateBannerTag(r.Context(), &bannerTag)
-			if err != nil {
-				render.Status(r, http.StatusBadRequest)
-				log.Error("Failed to create banner tag", errMsg.Err(err))
-				render.JSON(w, r, response.Error("Failed to create banner tag"))
-				return
-			}
-		}
-		log.Info("banner-tegs added")
+		auditor.Record(r.Context(), audit.Event{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityBanner,
+			EntityID:   audit.ID(banner.ID),
+			After:      banner,
+		})
 		responseOK(w, r, banner)
 	}
 }
 
 func responseOK(w http.ResponseWriter, r *http.Request, banner structs.Banner) {
+	w.Header().Set("ETag", bannerETag(banner))
 	render.JSON(w, r, ResponseBanner{
-		Response:  response.OK(),
-		ID:        banner.ID,
-		TagIDs:    banner.TagIDs,
-		FeatureID: banner.FeatureID,
-		Content:   banner.Content,
-		IsActive:  banner.IsActive,
+		Response:    response.OK(),
+		ID:          banner.ID,
+		TagIDs:      banner.TagIDs,
+		FeatureID:   banner.FeatureID,
+		Content:     banner.Content,
+		IsActive:    banner.IsActive,
+		ActiveFrom:  banner.ActiveFrom,
+		ActiveUntil: banner.ActiveUntil,
+		Version:     banner.Version,
+	})
+}
+
+func responseConflict(w http.ResponseWriter, r *http.Request, conflict *storage.BannerConflictError) {
+	render.Status(r, http.StatusConflict)
+	render.JSON(w, r, ResponseConflict{
+		Response: response.Error(conflict.Error()),
+		BannerID: conflict.BannerID,
+	})
+}
+
+func responseUnknownReferences(w http.ResponseWriter, r *http.Request, unknown *storage.UnknownReferencesError) {
+	render.Status(r, http.StatusBadRequest)
+	render.JSON(w, r, ResponseUnknownReferences{
+		Response:         response.Error(unknown.Error()),
+		UnknownFeatureID: unknown.FeatureID,
+		UnknownTagIDs:    unknown.TagIDs,
 	})
 }
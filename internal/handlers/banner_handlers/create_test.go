@@ -0,0 +1,101 @@
+package bannerhandlers
+
+import (
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/audit"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeBanners fails CreateBanner with err. Other methods are not used.
+type fakeBanners struct {
+	Banners
+	err error
+}
+
+func (b fakeBanners) CreateBanner(ctx context.Context, banner *structs.Banner) error {
+	return b.err
+}
+
+type nopAuditor struct{}
+
+func (nopAuditor) Record(ctx context.Context, event audit.Event) {}
+
+func TestCreateBannerErrors(t *testing.T) {
+	featureID := 2
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBanner int
+	}{
+		{
+			name:       "feature and tag pair taken",
+			err:        &storage.BannerConflictError{FeatureID: 2, TagID: 1, BannerID: 42},
+			wantStatus: http.StatusConflict,
+			wantBanner: 42,
+		},
+		{
+			name:       "unknown feature",
+			err:        &storage.UnknownReferencesError{FeatureID: &featureID},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "other error",
+			err:        errors.New("connection reset"),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	body := `{"tag_ids":[1],"feature_id":2,"content":{"title":"t"},"is_active":true}`
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/banners", strings.NewReader(body))
+			w := httptest.NewRecorder()
+
+			New(log, fakeBanners{err: tt.err}, nopAuditor{})(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			var resp ResponseConflict
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.BannerID != tt.wantBanner {
+				t.Errorf("banner_id = %d, want %d", resp.BannerID, tt.wantBanner)
+			}
+		})
+	}
+}
+
+func TestCreateInactiveBanner(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	body := `{"tag_ids":[1],"feature_id":2,"content":{"title":"t"},"is_active":false}`
+	r := httptest.NewRequest(http.MethodPost, "/banners", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	New(log, fakeBanners{}, nopAuditor{})(w, r)
+
+	var resp ResponseBanner
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != response.StatusOK {
+		t.Fatalf("status = %q, want %q: %s", resp.Status, response.StatusOK, w.Body)
+	}
+	if resp.IsActive {
+		t.Errorf("is_active = true, want false")
+	}
+}
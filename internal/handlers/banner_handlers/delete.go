@@ -2,7 +2,16 @@ package bannerhandlers
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
 	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/audit"
+	"banner-serivce/internal/auth/jwt"
+	"banner-serivce/internal/jobs"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"context"
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -12,12 +21,20 @@ import (
 	"github.com/go-chi/render"
 )
 
-func NewDeleteBannerHandler(log *slog.Logger, bannerRepo Banners) http.HandlerFunc {
+// BannerEditor changes banners through the shared paths that keep the
+// /user_banner cache and the audit log in step, see banners.Editor.
+type BannerEditor interface {
+	Update(ctx context.Context, before structs.Banner, banner *structs.Banner) error
+	ReplaceVariants(ctx context.Context, id int, variants []structs.BannerVariant) ([]structs.BannerVariant, error)
+	Activate(ctx context.Context, id, version int, ifVersions []int) (structs.Banner, error)
+	Delete(ctx context.Context, id int, ifVersions []int, action string) (structs.Banner, error)
+	DeleteMatching(ctx context.Context, featureID, tagID *int, action string, progress func(total, done int)) (int, error)
+}
+
+func NewDeleteBannerHandler(log *slog.Logger, editor BannerEditor) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const loggerOptions = "handlers.banners.deleteBanner.New"
-		log := log.With(
-			slog.String("options", loggerOptions),
-			slog.String("request_id", middleware.GetReqID(r.Context())))
+		log := requestlog.New(log, r, loggerOptions)
 
 		idStr := chi.URLParam(r, "id")
 		id, err := strconv.Atoi(idStr)
@@ -27,14 +44,122 @@ func NewDeleteBannerHandler(log *slog.Logger, bannerRepo Banners) http.HandlerFu
 			return
 		}
 
-		err = bannerRepo.DeleteBannerByID(r.Context(), id)
+		_, err = editor.Delete(r.Context(), id, ifMatchVersions(r), audit.ActionDelete)
 		if err != nil {
+			if errors.Is(err, storage.ErrBannerNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Banner not found"))
+				return
+			}
+			if errors.Is(err, storage.ErrVersionMismatch) {
+				render.Status(r, http.StatusPreconditionFailed)
+				render.JSON(w, r, response.Error("Banner was modified, fetch it again"))
+				return
+			}
 			log.Error("Failed to delete banner", errMsg.Err(err))
 			render.Status(r, http.StatusInternalServerError)
 			render.JSON(w, r, response.Error("Failed to delete banner"))
 			return
 		}
 		log.Info("Banner deleted")
-		render.Status(r, http.StatusNoContent)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeleteBannersJob is the kind of the job queued by NewDeleteBannersHandler.
+const DeleteBannersJob = "delete_banners"
+
+type JobQueue interface {
+	Enqueue(ctx context.Context, kind string, params any) (jobs.Job, error)
+}
+
+type RequestDeleteBanners struct {
+	FeatureID *int `json:"feature_id"`
+	TagID     *int `json:"tag_id"`
+}
+
+// deleteBannersParams is stored with a delete_banners job. The job may run
+// on another replica after the request is gone, so the caller and the
+// request id are kept for the audit log.
+type deleteBannersParams struct {
+	RequestDeleteBanners
+	Actor     string `json:"actor"`
+	RequestID string `json:"request_id"`
+}
+
+type ResponseJob struct {
+	response.Response
+	JobID string `json:"job_id"`
+}
+
+// NewDeleteBannersHandler queues a background job deleting every banner that
+// matches feature_id and/or tag_id, and answers 202 with the job id.
+func NewDeleteBannersHandler(log *slog.Logger, jobQueue JobQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.banners.deleteBanners.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		var req RequestDeleteBanners
+		if featureIDStr := r.URL.Query().Get("feature_id"); featureIDStr != "" {
+			featureID, err := strconv.Atoi(featureIDStr)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error("Invalid feature_id"))
+				return
+			}
+			req.FeatureID = &featureID
+		}
+
+		if tagIDStr := r.URL.Query().Get("tag_id"); tagIDStr != "" {
+			tagID, err := strconv.Atoi(tagIDStr)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error("Invalid tag_id"))
+				return
+			}
+			req.TagID = &tagID
+		}
+
+		if req.FeatureID == nil && req.TagID == nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("feature_id or tag_id is required"))
+			return
+		}
+
+		params := deleteBannersParams{RequestDeleteBanners: req, RequestID: middleware.GetReqID(r.Context())}
+		if principal, ok := jwt.PrincipalFromContext(r.Context()); ok {
+			params.Actor = principal.Username
+		}
+
+		job, err := jobQueue.Enqueue(r.Context(), DeleteBannersJob, params)
+		if err != nil {
+			log.Error("Failed to enqueue banner deletion", errMsg.Err(err))
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, response.Error("Failed to enqueue banner deletion"))
+			return
+		}
+
+		log.Info("Banner deletion queued", slog.String("job_id", job.ID))
+		w.Header().Set("Location", "/jobs/"+job.ID)
+		render.Status(r, http.StatusAccepted)
+		render.JSON(w, r, ResponseJob{Response: response.OK(), JobID: job.ID})
+	}
+}
+
+// NewDeleteBannersJob runs delete_banners jobs. Each banner is deleted and
+// audited on its own, on behalf of the caller who queued the job.
+func NewDeleteBannersJob(editor BannerEditor) jobs.Handler {
+	return func(ctx context.Context, rawParams json.RawMessage, progress func(total, done int)) error {
+		var params deleteBannersParams
+		if err := json.Unmarshal(rawParams, &params); err != nil {
+			return err
+		}
+
+		ctx = context.WithValue(ctx, middleware.RequestIDKey, params.RequestID)
+		if params.Actor != "" {
+			ctx = jwt.WithPrincipal(ctx, jwt.Principal{Username: params.Actor})
+		}
+		_, err := editor.DeleteMatching(ctx, params.FeatureID, params.TagID, audit.ActionBulkDelete, progress)
+		return err
 	}
 }
@@ -0,0 +1,79 @@
+package bannerhandlers
+
+import (
+	"banner-serivce/internal/structs"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// bannerETag is a strong validator for the admin view of a banner. It changes
+// with every revision.
+func bannerETag(banner structs.Banner) string {
+	return `"v` + strconv.Itoa(banner.Version) + `"`
+}
+
+// ifMatchVersions returns the banner versions accepted by the If-Match header.
+// nil means the header is absent or "*" and any version is accepted; an empty
+// slice means none of the listed tags can match.
+func ifMatchVersions(r *http.Request) []int {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := []int{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if !strings.HasPrefix(tag, `"v`) || !strings.HasSuffix(tag, `"`) {
+			continue
+		}
+		version, err := strconv.Atoi(tag[2 : len(tag)-1])
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// contentETag is a strong validator for the content served on /user_banner.
+// It covers the revision and the served variant as well, so restoring older
+// content or switching a user to another variant still yields a new tag.
+func contentETag(banner structs.Banner, variant *structs.BannerVariant) (string, error) {
+	servedContent, variantID := banner.Content, 0
+	if variant != nil {
+		servedContent, variantID = variant.Content, variant.ID
+	}
+	content, err := json.Marshal(servedContent)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(strconv.Itoa(banner.ID) + ":" + strconv.Itoa(banner.Version) + ":" + strconv.Itoa(variantID) + ":"))
+	h.Write(content)
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`, nil
+}
+
+// ifNoneMatch reports whether the If-None-Match header matches etag, using the
+// weak comparison RFC 9110 prescribes for this header.
+func ifNoneMatch(r *http.Request, etag string) bool {
+	header := strings.TrimSpace(r.Header.Get("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package bannerhandlers
+
+import (
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestIfMatchVersions(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []int
+	}{
+		{name: "absent", header: "", want: nil},
+		{name: "any", header: "*", want: nil},
+		{name: "any with spaces", header: " * ", want: nil},
+		{name: "one version", header: `"v3"`, want: []int{3}},
+		{name: "several versions", header: `"v3", "v5" ,"v7"`, want: []int{3, 5, 7}},
+		{name: "weak tag ignored", header: `W/"v3"`, want: []int{}},
+		{name: "content tag ignored", header: `"0f1e2d", "v4"`, want: []int{4}},
+		{name: "unquoted ignored", header: `v3`, want: []int{}},
+		{name: "bad number ignored", header: `"vx"`, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PATCH", "/banner/1", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			got := ifMatchVersions(r)
+			if (got == nil) != (tt.want == nil) || !slices.Equal(got, tt.want) {
+				t.Errorf("ifMatchVersions() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
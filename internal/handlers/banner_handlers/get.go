@@ -1,11 +1,18 @@
 package bannerhandlers
 
 import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
 	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 )
 
@@ -14,11 +21,22 @@ type RequestGetBanners struct {
 	TagID     *int `json:"tag_id"`
 	Limit     *int `json:"limit"`
 	Offset    *int `json:"offset"`
+	// State narrows the list to live, upcoming or expired banners; empty
+	// means any.
+	State string `json:"state"`
 }
 
 func NewGetBannersHandler(bannerRepo Banners, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		req := parseGetBannersRequest(r)
+		const loggerOptions = "handlers.banners.getBanners.New"
+		logger := requestlog.New(logger, r, loggerOptions)
+
+		req, err := parseGetBannersRequest(r)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
 
 		banners, err := bannerRepo.FindBannersByParameters(r.Context(), req)
 		if err != nil {
@@ -32,7 +50,38 @@ func NewGetBannersHandler(bannerRepo Banners, logger *slog.Logger) http.HandlerF
 	}
 }
 
-func parseGetBannersRequest(r *http.Request) RequestGetBanners {
+// NewGetBannerByIDHandler returns a single banner with an ETag that PATCH and
+// DELETE accept in If-Match.
+func NewGetBannerByIDHandler(log *slog.Logger, bannerRepo Banners) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.banners.getBannerByID.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid banner ID"))
+			return
+		}
+
+		banner, err := bannerRepo.FindBannerByID(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, storage.ErrBannerNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Banner not found"))
+				return
+			}
+			log.Error("Failed to get banner", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get banner"))
+			return
+		}
+
+		responseOK(w, r, banner)
+	}
+}
+
+func parseGetBannersRequest(r *http.Request) (RequestGetBanners, error) {
 	req := RequestGetBanners{}
 
 	if featureIDStr := r.URL.Query().Get("feature_id"); featureIDStr != "" {
@@ -55,5 +104,13 @@ func parseGetBannersRequest(r *http.Request) RequestGetBanners {
 		req.Offset = &offset
 	}
 
-	return req
+	switch state := r.URL.Query().Get("state"); state {
+	case "", structs.ScheduleLive, structs.ScheduleUpcoming, structs.ScheduleExpired:
+		req.State = state
+	default:
+		return RequestGetBanners{}, fmt.Errorf("invalid state %q, use %s, %s or %s",
+			state, structs.ScheduleLive, structs.ScheduleUpcoming, structs.ScheduleExpired)
+	}
+
+	return req, nil
 }
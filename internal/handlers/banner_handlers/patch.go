@@ -0,0 +1,100 @@
+package bannerhandlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+const mergePatchMediaType = "application/merge-patch+json"
+
+// isMergePatch reports whether the body is a JSON merge patch (RFC 7386), in
+// which case content is merged into the stored one instead of replacing it.
+func isMergePatch(r *http.Request) (bool, error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return false, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false, fmt.Errorf("invalid Content-Type %q", contentType)
+	}
+	switch mediaType {
+	case "application/json":
+		return false, nil
+	case mergePatchMediaType:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported Content-Type %q, use application/json or %s", mediaType, mergePatchMediaType)
+	}
+}
+
+// decodeUpdateBannerRequest decodes a PATCH body. Fields that are present but
+// null are rejected, except the activation window bounds, which null clears.
+func decodeUpdateBannerRequest(r *http.Request) (RequestUpdateBanner, error) {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+		return RequestUpdateBanner{}, errors.New("failed to decode request")
+	}
+
+	var req RequestUpdateBanner
+	for name, raw := range fields {
+		var target interface{}
+		switch name {
+		case "tag_ids":
+			target = &req.TagIDs
+		case "feature_id":
+			target = &req.FeatureID
+		case "content":
+			target = &req.Content
+		case "is_active":
+			target = &req.IsActive
+		case "active_from":
+			target = &req.ActiveFrom
+		case "active_until":
+			target = &req.ActiveUntil
+		default:
+			return RequestUpdateBanner{}, fmt.Errorf("field %s is not known", name)
+		}
+
+		if _, clearable := target.(*optionalTime); string(raw) == "null" && !clearable {
+			return RequestUpdateBanner{}, fmt.Errorf("field %s cannot be null", name)
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			return RequestUpdateBanner{}, fmt.Errorf("field %s is not valid", name)
+		}
+	}
+
+	if req.TagIDs != nil && len(*req.TagIDs) == 0 {
+		return RequestUpdateBanner{}, errors.New("field tag_ids cannot be empty")
+	}
+	return req, nil
+}
+
+// mergePatch applies patch to target following RFC 7386: null removes a key,
+// objects are merged recursively and any other value replaces the old one.
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(target)+len(patch))
+	for k, v := range target {
+		merged[k] = v
+	}
+
+	for k, v := range patch {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		patchObj, ok := v.(map[string]interface{})
+		if !ok {
+			merged[k] = v
+			continue
+		}
+		targetObj, _ := merged[k].(map[string]interface{})
+		merged[k] = mergePatch(targetObj, patchObj)
+	}
+
+	return merged
+}
@@ -0,0 +1,74 @@
+package bannerhandlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target map[string]interface{}
+		patch  map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			name:   "add and replace",
+			target: map[string]interface{}{"title": "old", "text": "kept"},
+			patch:  map[string]interface{}{"title": "new", "url": "u"},
+			want:   map[string]interface{}{"title": "new", "text": "kept", "url": "u"},
+		},
+		{
+			name:   "null removes",
+			target: map[string]interface{}{"title": "t", "text": "x"},
+			patch:  map[string]interface{}{"text": nil},
+			want:   map[string]interface{}{"title": "t"},
+		},
+		{
+			name:   "null on missing key",
+			target: map[string]interface{}{"title": "t"},
+			patch:  map[string]interface{}{"text": nil},
+			want:   map[string]interface{}{"title": "t"},
+		},
+		{
+			name:   "objects merge recursively",
+			target: map[string]interface{}{"style": map[string]interface{}{"color": "red", "size": 1.0}},
+			patch:  map[string]interface{}{"style": map[string]interface{}{"color": "blue", "size": nil}},
+			want:   map[string]interface{}{"style": map[string]interface{}{"color": "blue"}},
+		},
+		{
+			name:   "object replaces scalar",
+			target: map[string]interface{}{"style": "plain"},
+			patch:  map[string]interface{}{"style": map[string]interface{}{"color": "blue"}},
+			want:   map[string]interface{}{"style": map[string]interface{}{"color": "blue"}},
+		},
+		{
+			name:   "arrays are replaced",
+			target: map[string]interface{}{"tags": []interface{}{"a", "b"}},
+			patch:  map[string]interface{}{"tags": []interface{}{"c"}},
+			want:   map[string]interface{}{"tags": []interface{}{"c"}},
+		},
+		{
+			name:   "nil target",
+			target: nil,
+			patch:  map[string]interface{}{"title": "t"},
+			want:   map[string]interface{}{"title": "t"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergePatch(tt.target, tt.patch); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergePatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchLeavesTargetAlone(t *testing.T) {
+	target := map[string]interface{}{"title": "old"}
+	mergePatch(target, map[string]interface{}{"title": nil})
+	if target["title"] != "old" {
+		t.Errorf("target changed to %v", target)
+	}
+}
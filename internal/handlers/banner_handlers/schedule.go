@@ -0,0 +1,37 @@
+package bannerhandlers
+
+import (
+	"banner-serivce/internal/structs"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// optionalTime is a PATCH field that may be absent, set to a time, or set to
+// null to clear it.
+type optionalTime struct {
+	Set   bool
+	Value *time.Time
+}
+
+func (t *optionalTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	if string(data) == "null" {
+		t.Value = nil
+		return nil
+	}
+
+	var value time.Time
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	t.Value = structs.InUTC(&value)
+	return nil
+}
+
+func validateSchedule(banner structs.Banner) error {
+	if banner.ActiveFrom != nil && banner.ActiveUntil != nil && !banner.ActiveFrom.Before(*banner.ActiveUntil) {
+		return errors.New("active_from must be before active_until")
+	}
+	return nil
+}
@@ -2,27 +2,37 @@ package bannerhandlers
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
 	"banner-serivce/internal/api/response"
-	"encoding/json"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
-	"github.com/go-playground/validator"
 )
 
+// RequestUpdateBanner holds the fields of a PATCH body. A nil field was not
+// sent and keeps its current value.
 type RequestUpdateBanner struct {
-	TagIDs    []int                  `json:"tag_ids" validate:"required"`
-	FeatureID int                    `json:"feature_id" validate:"required"`
-	Content   map[string]interface{} `json:"content" validate:"required"`
-	IsActive  bool                   `json:"is_active" validate:"required"`
+	TagIDs      *[]int                 `json:"tag_ids"`
+	FeatureID   *int                   `json:"feature_id"`
+	Content     map[string]interface{} `json:"content"`
+	IsActive    *bool                  `json:"is_active"`
+	ActiveFrom  optionalTime           `json:"active_from"`
+	ActiveUntil optionalTime           `json:"active_until"`
 }
 
-func NewUpdateBannerHandler(bannerRepo Banners, logger *slog.Logger) http.HandlerFunc {
+func NewUpdateBannerHandler(bannerRepo Banners, logger *slog.Logger, editor BannerEditor) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.banners.updateBanner.New"
+		logger := requestlog.New(logger, r, loggerOptions)
+
 		bannerID, err := strconv.Atoi(chi.URLParam(r, "id"))
 		if err != nil {
 			render.Status(r, http.StatusBadRequest)
@@ -31,34 +41,83 @@ func NewUpdateBannerHandler(bannerRepo Banners, logger *slog.Logger) http.Handle
 			return
 		}
 
-		var req RequestUpdateBanner
+		mergeContent, err := isMergePatch(r)
+		if err != nil {
+			render.Status(r, http.StatusUnsupportedMediaType)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
 
-		err = json.NewDecoder(r.Body).Decode(&req)
+		req, err := decodeUpdateBannerRequest(r)
 		if err != nil {
-			validateErr := err.(validator.ValidationErrors)
 			logger.Error("Invalid request", errMsg.Err(err))
-			render.JSON(w, r, response.ValidationError(validateErr))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
 			return
 		}
 
+		ifVersions := ifMatchVersions(r)
 		banner, err := bannerRepo.FindBannerByID(r.Context(), bannerID)
 		if err != nil {
-			render.Status(r, http.StatusNotFound)
-			logger.Error("Failed to find banner")
-			render.JSON(w, r, response.Error("Banner not found"))
+			if errors.Is(err, storage.ErrBannerNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Banner not found"))
+				return
+			}
+			logger.Error("Failed to find banner", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to update banner"))
+			return
+		}
+		if ifVersions != nil && !slices.Contains(ifVersions, banner.Version) {
+			render.Status(r, http.StatusPreconditionFailed)
+			render.JSON(w, r, response.Error("Banner was modified, fetch it again"))
 			return
 		}
+		before := banner
 
-		banner.TagIDs = req.TagIDs
-		banner.FeatureID = req.FeatureID
-		banner.Content = req.Content
-		banner.IsActive = req.IsActive
+		banner = applyUpdate(banner, req, mergeContent)
+		if err := validateSchedule(banner); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
 		banner.UpdatedAt = time.Now()
 
-		err = bannerRepo.UpdateBanner(r.Context(), &banner)
+		// The write expects the version just read, so a concurrent update in
+		// between is never overwritten; the client gets 409 and sends the
+		// change again.
+		err = editor.Update(r.Context(), before, &banner)
 		if err != nil {
+			var conflict *storage.BannerConflictError
+			if errors.As(err, &conflict) {
+				logger.Info("Feature and tag pair already taken", slog.Int("banner_id", conflict.BannerID))
+				responseConflict(w, r, conflict)
+				return
+			}
+			var unknown *storage.UnknownReferencesError
+			if errors.As(err, &unknown) {
+				logger.Info("Banner references unknown ids", errMsg.Err(err))
+				responseUnknownReferences(w, r, unknown)
+				return
+			}
+			if errors.Is(err, storage.ErrBannerNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Banner not found"))
+				return
+			}
+			if errors.Is(err, storage.ErrVersionMismatch) && ifVersions != nil {
+				render.Status(r, http.StatusPreconditionFailed)
+				render.JSON(w, r, response.Error("Banner was modified, fetch it again"))
+				return
+			}
+			if errors.Is(err, storage.ErrVersionMismatch) {
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, response.Error("Banner is being modified concurrently, try again"))
+				return
+			}
 			render.Status(r, http.StatusInternalServerError)
-			logger.Error("Failed to update banner")
+			logger.Error("Failed to update banner", errMsg.Err(err))
 			render.JSON(w, r, response.Error("Failed to update banner"))
 			return
 		}
@@ -67,3 +126,30 @@ func NewUpdateBannerHandler(bannerRepo Banners, logger *slog.Logger) http.Handle
 	}
 
 }
+
+// applyUpdate returns banner with the fields sent in req changed.
+func applyUpdate(banner structs.Banner, req RequestUpdateBanner, mergeContent bool) structs.Banner {
+	if req.TagIDs != nil {
+		banner.TagIDs = *req.TagIDs
+	}
+	if req.FeatureID != nil {
+		banner.FeatureID = *req.FeatureID
+	}
+	if req.Content != nil {
+		if mergeContent {
+			banner.Content = mergePatch(banner.Content, req.Content)
+		} else {
+			banner.Content = req.Content
+		}
+	}
+	if req.IsActive != nil {
+		banner.IsActive = *req.IsActive
+	}
+	if req.ActiveFrom.Set {
+		banner.ActiveFrom = req.ActiveFrom.Value
+	}
+	if req.ActiveUntil.Set {
+		banner.ActiveUntil = req.ActiveUntil.Value
+	}
+	return banner
+}
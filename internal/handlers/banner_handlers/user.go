@@ -2,17 +2,30 @@ package bannerhandlers
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
 	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/auth"
+	"banner-serivce/internal/auth/jwt"
 	"banner-serivce/internal/structs"
+	"context"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
-	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 )
 
+type BannerCache interface {
+	GetBanner(ctx context.Context, featureID, tagID int, useLastRevision bool) (*structs.Banner, error)
+	GetBanners(ctx context.Context, pairs []structs.FeatureTag, useLastRevision bool) (map[structs.FeatureTag]structs.Banner, error)
+}
+
+// inactiveBannerHeader marks a banner that is disabled or outside its
+// activation window, served to an admin for preview.
+const inactiveBannerHeader = "X-Banner-Inactive"
+
 type RequestGetBanner struct {
 	FeatureID       int  `json:"feature_id" validate:"required"`
 	TagID           int  `json:"tag_id" validate:"required"`
@@ -25,12 +38,15 @@ type Content struct {
 	Name string `json:"name"`
 }
 
-func NewGetBannerHandler(log *slog.Logger, bannerRepo Banners) http.HandlerFunc {
+// NewGetBannerHandler serves the banner content to clients. cacheControl is
+// sent as the Cache-Control header, and a matching If-None-Match is answered
+// with 304 Not Modified. When the banner runs an A/B experiment, the variant
+// is picked by the user_id query parameter, or by the username in the token
+// when it is absent.
+func NewGetBannerHandler(log *slog.Logger, bannerCache BannerCache, cacheControl string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const loggerOptions = "handlers.banners.userBanner.New"
-		log := log.With(
-			slog.String("options", loggerOptions),
-			slog.String("request_id", chi.URLParam(r, "request_id")))
+		log := requestlog.New(log, r, loggerOptions)
 
 		featureIDStr := r.URL.Query().Get("feature_id")
 		tagIDStr := r.URL.Query().Get("tag_id")
@@ -69,13 +85,54 @@ func NewGetBannerHandler(log *slog.Logger, bannerRepo Banners) http.HandlerFunc
 			return
 		}
 
-		banner, err := bannerRepo.FindBannerByFeatureTag(r.Context(), req.FeatureID, req.TagID)
+		banner, err := bannerCache.GetBanner(r.Context(), req.FeatureID, req.TagID, req.UseLastRevision)
 		if err != nil {
 			log.Error("Failed to find banner", errMsg.Err(err))
 			render.Status(r, http.StatusNotFound)
 			render.JSON(w, r, response.Error("Failed to find banner"))
 			return
 		}
+
+		principal, _ := jwt.PrincipalFromContext(r.Context())
+
+		if !banner.IsLive(time.Now()) {
+			if principal.Role != auth.RoleAdmin {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Failed to find banner"))
+				return
+			}
+			w.Header().Set(inactiveBannerHeader, "true")
+		}
+
+		subject := r.URL.Query().Get("user_id")
+		if subject == "" {
+			subject = principal.Username
+		}
+		variant := chooseVariant(*banner, subject)
+		if variant != nil {
+			w.Header().Set(variantHeader, strconv.Itoa(variant.ID))
+		}
+
+		etag, err := contentETag(*banner, variant)
+		if err != nil {
+			log.Error("Failed to compute banner ETag", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to find banner"))
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Vary", "Authorization")
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		if ifNoneMatch(r, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if variant != nil {
+			render.JSON(w, r, variant.Content)
+			return
+		}
 		responseGetOK(w, r, *banner)
 	}
 }
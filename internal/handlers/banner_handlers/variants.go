@@ -0,0 +1,143 @@
+package bannerhandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+)
+
+// variantHeader carries the id of the A/B variant served on /user_banner.
+const variantHeader = "X-Banner-Variant"
+
+type RequestBannerVariant struct {
+	Content map[string]interface{} `json:"content" validate:"required"`
+	Weight  int                    `json:"weight" validate:"min=1,max=10000"`
+}
+
+// RequestBannerVariants replaces every variant of a banner. An empty list
+// turns the experiment off.
+type RequestBannerVariants struct {
+	Variants []RequestBannerVariant `json:"variants" validate:"max=100,dive"`
+}
+
+func NewGetBannerVariantsHandler(log *slog.Logger, bannerRepo Banners) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.banners.getBannerVariants.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		bannerID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid banner ID"))
+			return
+		}
+
+		variants, err := bannerRepo.FindBannerVariants(r.Context(), bannerID)
+		if err != nil {
+			if errors.Is(err, storage.ErrBannerNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Banner not found"))
+				return
+			}
+			log.Error("Failed to get banner variants", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get banner variants"))
+			return
+		}
+
+		render.JSON(w, r, variants)
+	}
+}
+
+func NewReplaceBannerVariantsHandler(log *slog.Logger, editor BannerEditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.banners.replaceBannerVariants.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		bannerID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid banner ID"))
+			return
+		}
+
+		var req RequestBannerVariants
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("Failed to decode request body", errMsg.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Failed to decode request"))
+			return
+		}
+		if req.Variants == nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("field variants is a required field"))
+			return
+		}
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("Invalid request", errMsg.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		variants := make([]structs.BannerVariant, 0, len(req.Variants))
+		for _, variant := range req.Variants {
+			variants = append(variants, structs.BannerVariant{Content: variant.Content, Weight: variant.Weight})
+		}
+
+		variants, err = editor.ReplaceVariants(r.Context(), bannerID, variants)
+		if err != nil {
+			if errors.Is(err, storage.ErrBannerNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Banner not found"))
+				return
+			}
+			log.Error("Failed to replace banner variants", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to replace banner variants"))
+			return
+		}
+
+		log.Info("Banner variants replaced", slog.Int("banner_id", bannerID), slog.Int("variants", len(variants)))
+		render.JSON(w, r, variants)
+	}
+}
+
+// chooseVariant picks the variant served to subject. The pick depends only on
+// the banner, the subject and the variant weights, so a user keeps seeing the
+// same variant until the experiment is changed. It returns nil when the
+// banner has no variants.
+func chooseVariant(banner structs.Banner, subject string) *structs.BannerVariant {
+	total := 0
+	for _, variant := range banner.Variants {
+		total += variant.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(strconv.Itoa(banner.ID) + ":" + subject))
+	point := int(h.Sum64() % uint64(total))
+
+	for i := range banner.Variants {
+		point -= banner.Variants[i].Weight
+		if point < 0 {
+			return &banner.Variants[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package bannerhandlers
+
+import (
+	"banner-serivce/internal/structs"
+	"strconv"
+	"testing"
+)
+
+func TestChooseVariant(t *testing.T) {
+	a := structs.BannerVariant{ID: 1, Weight: 1}
+	b := structs.BannerVariant{ID: 2, Weight: 3}
+
+	tests := []struct {
+		name     string
+		variants []structs.BannerVariant
+		wantNil  bool
+		wantOnly int
+	}{
+		{name: "no variants", variants: nil, wantNil: true},
+		{name: "zero weights", variants: []structs.BannerVariant{{ID: 1}, {ID: 2}}, wantNil: true},
+		{name: "single variant", variants: []structs.BannerVariant{a}, wantOnly: a.ID},
+		{name: "zero weight never chosen", variants: []structs.BannerVariant{{ID: 1}, b}, wantOnly: b.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			banner := structs.Banner{ID: 10, Variants: tt.variants}
+			for i := 0; i < 100; i++ {
+				got := chooseVariant(banner, "user-"+strconv.Itoa(i))
+				if tt.wantNil {
+					if got != nil {
+						t.Fatalf("chooseVariant() = %+v, want nil", got)
+					}
+					continue
+				}
+				if got == nil || got.ID != tt.wantOnly {
+					t.Fatalf("chooseVariant() = %+v, want variant %d", got, tt.wantOnly)
+				}
+			}
+		})
+	}
+}
+
+func TestChooseVariantIsStickyAndWeighted(t *testing.T) {
+	banner := structs.Banner{ID: 10, Variants: []structs.BannerVariant{{ID: 1, Weight: 1}, {ID: 2, Weight: 3}}}
+
+	const subjects = 4000
+	counts := map[int]int{}
+	for i := 0; i < subjects; i++ {
+		subject := "user-" + strconv.Itoa(i)
+		first := chooseVariant(banner, subject)
+		if again := chooseVariant(banner, subject); again.ID != first.ID {
+			t.Fatalf("subject %s got variant %d, then %d", subject, first.ID, again.ID)
+		}
+		counts[first.ID]++
+	}
+
+	// Variant 1 has a quarter of the weight; allow a wide margin, the point
+	// is that the weights are honoured at all.
+	if share := float64(counts[1]) / subjects; share < 0.2 || share > 0.3 {
+		t.Errorf("variant 1 served to %.2f of subjects, want about 0.25", share)
+	}
+}
@@ -0,0 +1,105 @@
+package bannerhandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func NewGetBannerVersionsHandler(log *slog.Logger, bannerRepo Banners) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.banners.getBannerVersions.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		bannerID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid banner ID"))
+			return
+		}
+
+		revisions, err := bannerRepo.FindBannerRevisions(r.Context(), bannerID)
+		if err != nil {
+			if errors.Is(err, storage.ErrBannerNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Banner not found"))
+				return
+			}
+			log.Error("Failed to get banner versions", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get banner versions"))
+			return
+		}
+
+		render.JSON(w, r, revisions)
+	}
+}
+
+// NewActivateBannerVersionHandler restores a banner to a stored revision.
+// Like PATCH and DELETE it honours If-Match.
+func NewActivateBannerVersionHandler(log *slog.Logger, editor BannerEditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.banners.activateBannerVersion.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		bannerID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid banner ID"))
+			return
+		}
+
+		version, err := strconv.Atoi(chi.URLParam(r, "version"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid version"))
+			return
+		}
+
+		banner, err := editor.Activate(r.Context(), bannerID, version, ifMatchVersions(r))
+		if err != nil {
+			if errors.Is(err, storage.ErrBannerNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Banner not found"))
+				return
+			}
+			if errors.Is(err, storage.ErrRevisionNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Banner version not found"))
+				return
+			}
+			if errors.Is(err, storage.ErrVersionMismatch) {
+				render.Status(r, http.StatusPreconditionFailed)
+				render.JSON(w, r, response.Error("Banner was modified, fetch it again"))
+				return
+			}
+			var conflict *storage.BannerConflictError
+			if errors.As(err, &conflict) {
+				log.Info("Feature and tag pair already taken", slog.Int("banner_id", conflict.BannerID))
+				responseConflict(w, r, conflict)
+				return
+			}
+			var unknown *storage.UnknownReferencesError
+			if errors.As(err, &unknown) {
+				log.Info("Banner version references unknown ids", errMsg.Err(err))
+				responseUnknownReferences(w, r, unknown)
+				return
+			}
+			log.Error("Failed to activate banner version", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to activate banner version"))
+			return
+		}
+
+		log.Info("Banner version activated", slog.Int("banner_id", bannerID), slog.Int("version", version))
+		responseOK(w, r, banner)
+	}
+}
@@ -2,19 +2,29 @@ package featurehandlers
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
 	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/audit"
 	"banner-serivce/internal/structs"
 	"context"
 	"log/slog"
 	"net/http"
 
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 )
 
 type Features interface {
 	CreateFeature(ctx context.Context, feature *structs.Feature) error
+	FindFeatureById(ctx context.Context, id int) (structs.Feature, error)
+	FindFeaturesByParameters(ctx context.Context, params RequestGetFeatures) ([]structs.Feature, error)
+	UpdateFeature(ctx context.Context, feature *structs.Feature) error
+	DeleteFeature(ctx context.Context, id int) error
+}
+
+// BannerEditor deletes banners through the banner delete, see banners.Editor.
+type BannerEditor interface {
+	DeleteMatching(ctx context.Context, featureID, tagID *int, action string, progress func(total, done int)) (int, error)
 }
 
 type RequestFeature struct {
@@ -27,12 +37,15 @@ type ResponseFeature struct {
 	Name string `json:"name"`
 }
 
-func New(log *slog.Logger, featureRepository Features) http.HandlerFunc {
+// Auditor records administrative changes, see audit.Recorder.
+type Auditor interface {
+	Record(ctx context.Context, event audit.Event)
+}
+
+func New(log *slog.Logger, featureRepository Features, auditor Auditor) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const loggerOptions = "handlers.features.createFeature.New"
-		log = log.With(
-			slog.String("options", loggerOptions),
-			slog.String("request_id", middleware.GetReqID(r.Context())))
+		log := requestlog.New(log, r, loggerOptions)
 
 		var req RequestFeature
 		err := render.DecodeJSON(r.Body, &req)
@@ -59,6 +72,12 @@ func New(log *slog.Logger, featureRepository Features) http.HandlerFunc {
 			return
 		}
 		log.Info("Feature added")
+		auditor.Record(r.Context(), audit.Event{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityFeature,
+			EntityID:   audit.ID(feature.ID),
+			After:      feature,
+		})
 		responseOK(w, r, req.Name, feature.ID)
 	}
 }
@@ -66,3 +85,13 @@ func responseOK(w http.ResponseWriter, r *http.Request, name string, feature_id
 	render.JSON(w, r, ResponseFeature{Response: response.OK(),
 		Name: name, ID: feature_id})
 }
+
+// auditedFeature returns the feature as it is before a change, for the audit log,
+// or nil when it cannot be read.
+func auditedFeature(ctx context.Context, featureRepository Features, id int) any {
+	feature, err := featureRepository.FindFeatureById(ctx, id)
+	if err != nil {
+		return nil
+	}
+	return feature
+}
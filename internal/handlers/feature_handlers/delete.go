@@ -0,0 +1,76 @@
+package featurehandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/audit"
+	"banner-serivce/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// NewDeleteFeatureHandler deletes a feature. A feature still referenced by
+// banners is only deleted, together with those banners, when ?cascade=true
+// is passed. The banners are deleted one by one like a single banner delete,
+// so each is audited and dropped from the cache.
+func NewDeleteFeatureHandler(log *slog.Logger, featureRepository Features, editor BannerEditor, auditor Auditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.features.deleteFeature.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid feature ID"))
+			return
+		}
+
+		cascade := false
+		if cascadeStr := r.URL.Query().Get("cascade"); cascadeStr != "" {
+			cascade, err = strconv.ParseBool(cascadeStr)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error("Invalid cascade"))
+				return
+			}
+		}
+
+		before := auditedFeature(r.Context(), featureRepository, id)
+		if cascade {
+			_, err = editor.DeleteMatching(r.Context(), &id, nil, audit.ActionDelete, nil)
+		}
+		if err == nil {
+			err = featureRepository.DeleteFeature(r.Context(), id)
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrFeatureNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Feature not found"))
+			case errors.Is(err, storage.ErrFeatureInUse):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, response.Error("Feature is used by banners, pass cascade=true to delete them too"))
+			default:
+				log.Error("Failed to delete feature", errMsg.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Failed to delete feature"))
+			}
+			return
+		}
+
+		log.Info("Feature deleted", slog.Int("feature_id", id), slog.Bool("cascade", cascade))
+		auditor.Record(r.Context(), audit.Event{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityFeature,
+			EntityID:   audit.ID(id),
+			Before:     before,
+		})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
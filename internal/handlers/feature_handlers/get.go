@@ -0,0 +1,87 @@
+package featurehandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/paging"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultFeaturesLimit = 100
+	maxFeaturesLimit     = 1000
+)
+
+type RequestGetFeatures struct {
+	Prefix string `json:"prefix"`
+	paging.Page
+}
+
+func NewGetFeaturesHandler(log *slog.Logger, featureRepository Features) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.features.getFeatures.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		req, err := parseGetFeaturesRequest(r)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		features, err := featureRepository.FindFeaturesByParameters(r.Context(), req)
+		if err != nil {
+			log.Error("Failed to get features", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get features"))
+			return
+		}
+
+		render.JSON(w, r, features)
+	}
+}
+
+func NewGetFeatureHandler(log *slog.Logger, featureRepository Features) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.features.getFeature.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid feature ID"))
+			return
+		}
+
+		feature, err := featureRepository.FindFeatureById(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, storage.ErrFeatureNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Feature not found"))
+				return
+			}
+			log.Error("Failed to get feature", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get feature"))
+			return
+		}
+
+		responseOK(w, r, feature.Name, feature.ID)
+	}
+}
+
+func parseGetFeaturesRequest(r *http.Request) (RequestGetFeatures, error) {
+	page, err := paging.Parse(r.URL.Query(), defaultFeaturesLimit, maxFeaturesLimit)
+	if err != nil {
+		return RequestGetFeatures{}, err
+	}
+	return RequestGetFeatures{Prefix: r.URL.Query().Get("prefix"), Page: page}, nil
+}
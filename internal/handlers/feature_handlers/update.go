@@ -0,0 +1,73 @@
+package featurehandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/audit"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+)
+
+func NewUpdateFeatureHandler(log *slog.Logger, featureRepository Features, auditor Auditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.features.updateFeature.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid feature ID"))
+			return
+		}
+
+		var req RequestFeature
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", errMsg.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Failed to decode request"))
+			return
+		}
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("Invalid request", errMsg.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		before := auditedFeature(r.Context(), featureRepository, id)
+		feature := structs.Feature{ID: id, Name: req.Name}
+		err = featureRepository.UpdateFeature(r.Context(), &feature)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrFeatureNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Feature not found"))
+			default:
+				log.Error("Failed to update feature", errMsg.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Failed to update feature"))
+			}
+			return
+		}
+
+		log.Info("Feature renamed", slog.Int("feature_id", id))
+		auditor.Record(r.Context(), audit.Event{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityFeature,
+			EntityID:   audit.ID(id),
+			Before:     before,
+			After:      feature,
+		})
+		responseOK(w, r, feature.Name, feature.ID)
+	}
+}
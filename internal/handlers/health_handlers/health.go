@@ -0,0 +1,42 @@
+package healthhandlers
+
+import (
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/health"
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+type Probe interface {
+	Check(ctx context.Context) health.Report
+}
+
+type ResponseReadiness struct {
+	response.Response
+	Checks map[string]health.CheckResult `json:"checks"`
+}
+
+// NewLivenessHandler reports that the process is up and serving requests.
+func NewLivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, response.OK())
+	}
+}
+
+// NewReadinessHandler reports whether every dependency is reachable, with a
+// breakdown per dependency. It answers 503 when any of them is not.
+func NewReadinessHandler(log *slog.Logger, probe Probe) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := probe.Check(r.Context())
+		if !report.Ready {
+			log.Warn("service is not ready", slog.Any("checks", report.Checks))
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, ResponseReadiness{Response: response.Error("not ready"), Checks: report.Checks})
+			return
+		}
+		render.JSON(w, r, ResponseReadiness{Response: response.OK(), Checks: report.Checks})
+	}
+}
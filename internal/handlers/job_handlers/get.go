@@ -0,0 +1,44 @@
+package jobhandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/jobs"
+	"banner-serivce/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type Jobs interface {
+	Get(ctx context.Context, id string) (jobs.Job, error)
+}
+
+func NewGetJobHandler(log *slog.Logger, jobManager Jobs) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.jobs.getJob.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		id := chi.URLParam(r, "id")
+		job, err := jobManager.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, storage.ErrJobNotFound) {
+				log.Info("Job not found", slog.String("job_id", id))
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Job not found"))
+				return
+			}
+			log.Error("Failed to get job", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get job"))
+			return
+		}
+
+		render.JSON(w, r, job)
+	}
+}
@@ -2,13 +2,16 @@ package taghandlers
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
 	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/audit"
+	"banner-serivce/internal/storage"
 	"banner-serivce/internal/structs"
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 )
@@ -25,13 +28,27 @@ type ResponseTag struct {
 
 type Tag interface {
 	CreateTag(ctx context.Context, tag *structs.Tag) error
+	FindTagById(ctx context.Context, id int) (structs.Tag, error)
+	FindTagsByParameters(ctx context.Context, params RequestGetTags) ([]structs.Tag, error)
+	UpdateTag(ctx context.Context, tag *structs.Tag) error
+	DeleteTag(ctx context.Context, id int) error
 }
-func New(log *slog.Logger, tagRepository Tag) http.HandlerFunc {
+
+// BannerEditor takes a tag off banners through the banner update, see
+// banners.Editor.
+type BannerEditor interface {
+	RemoveTag(ctx context.Context, tagID int) error
+}
+
+// Auditor records administrative changes, see audit.Recorder.
+type Auditor interface {
+	Record(ctx context.Context, event audit.Event)
+}
+
+func New(log *slog.Logger, tagRepository Tag, auditor Auditor) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const loggerOptions = "handlers.features.createTag.New"
-		log = log.With(
-			slog.String("options", loggerOptions),
-			slog.String("request_id", middleware.GetReqID(r.Context())))
+		log := requestlog.New(log, r, loggerOptions)
 
 		var req RequestTag
 		err := render.DecodeJSON(r.Body, &req)
@@ -50,11 +67,22 @@ func New(log *slog.Logger, tagRepository Tag) http.HandlerFunc {
 		tag := structs.Tag{Name: req.Name}
 		err = tagRepository.CreateTag(r.Context(), &tag)
 		if err != nil {
+			if errors.Is(err, storage.ErrTagExists) {
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, response.Error("Tag already exists"))
+				return
+			}
 			log.Error("Failed to create tag", errMsg.Err(err))
 			render.JSON(w, r, response.Error("Failed to create tag"))
 			return
 		}
 		log.Info("Tag added")
+		auditor.Record(r.Context(), audit.Event{
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityTag,
+			EntityID:   audit.ID(tag.ID),
+			After:      tag,
+		})
 		responseOK(w, r, req.Name, tag.ID)
 	}
 }
@@ -62,3 +90,13 @@ func responseOK(w http.ResponseWriter, r *http.Request, name string, tag_id int)
 	render.JSON(w, r, ResponseTag{Response: response.OK(),
 		Name: name, ID: tag_id})
 }
+
+// auditedTag returns the tag as it is before a change, for the audit log,
+// or nil when it cannot be read.
+func auditedTag(ctx context.Context, tagRepository Tag, id int) any {
+	tag, err := tagRepository.FindTagById(ctx, id)
+	if err != nil {
+		return nil
+	}
+	return tag
+}
@@ -0,0 +1,81 @@
+package taghandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/audit"
+	"banner-serivce/internal/storage"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// NewDeleteTagHandler deletes a tag. A tag still used by banners is only
+// deleted when ?force=true is passed; it is then taken off each banner as a
+// regular banner update. Banners whose only tag it is are never changed and
+// the request is refused with their ids.
+func NewDeleteTagHandler(log *slog.Logger, tagRepository Tag, editor BannerEditor, auditor Auditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.tags.deleteTag.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid tag ID"))
+			return
+		}
+
+		force := false
+		if forceStr := r.URL.Query().Get("force"); forceStr != "" {
+			force, err = strconv.ParseBool(forceStr)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error("Invalid force"))
+				return
+			}
+		}
+
+		before := auditedTag(r.Context(), tagRepository, id)
+		if force {
+			err = editor.RemoveTag(r.Context(), id)
+		}
+		if err == nil {
+			err = tagRepository.DeleteTag(r.Context(), id)
+		}
+		if err != nil {
+			var lastTag *storage.LastTagError
+			switch {
+			case errors.As(err, &lastTag):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, response.Error(fmt.Sprintf("Tag is the only tag of banners %v", lastTag.BannerIDs)))
+			case errors.Is(err, storage.ErrTagNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Tag not found"))
+			case errors.Is(err, storage.ErrTagInUse):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, response.Error("Tag is used by banners, pass force=true to delete it anyway"))
+			default:
+				log.Error("Failed to delete tag", errMsg.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Failed to delete tag"))
+			}
+			return
+		}
+
+		log.Info("Tag deleted", slog.Int("tag_id", id), slog.Bool("force", force))
+		auditor.Record(r.Context(), audit.Event{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityTag,
+			EntityID:   audit.ID(id),
+			Before:     before,
+		})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
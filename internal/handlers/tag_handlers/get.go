@@ -0,0 +1,87 @@
+package taghandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/paging"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultTagsLimit = 100
+	maxTagsLimit     = 1000
+)
+
+type RequestGetTags struct {
+	Prefix string `json:"prefix"`
+	paging.Page
+}
+
+func NewGetTagsHandler(log *slog.Logger, tagRepository Tag) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.tags.getTags.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		req, err := parseGetTagsRequest(r)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		tags, err := tagRepository.FindTagsByParameters(r.Context(), req)
+		if err != nil {
+			log.Error("Failed to get tags", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get tags"))
+			return
+		}
+
+		render.JSON(w, r, tags)
+	}
+}
+
+func NewGetTagHandler(log *slog.Logger, tagRepository Tag) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.tags.getTag.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid tag ID"))
+			return
+		}
+
+		tag, err := tagRepository.FindTagById(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, storage.ErrTagNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Tag not found"))
+				return
+			}
+			log.Error("Failed to get tag", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get tag"))
+			return
+		}
+
+		responseOK(w, r, tag.Name, tag.ID)
+	}
+}
+
+func parseGetTagsRequest(r *http.Request) (RequestGetTags, error) {
+	page, err := paging.Parse(r.URL.Query(), defaultTagsLimit, maxTagsLimit)
+	if err != nil {
+		return RequestGetTags{}, err
+	}
+	return RequestGetTags{Prefix: r.URL.Query().Get("prefix"), Page: page}, nil
+}
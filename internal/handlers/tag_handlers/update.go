@@ -0,0 +1,76 @@
+package taghandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/audit"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+)
+
+func NewUpdateTagHandler(log *slog.Logger, tagRepository Tag, auditor Auditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.tags.updateTag.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid tag ID"))
+			return
+		}
+
+		var req RequestTag
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", errMsg.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Failed to decode request"))
+			return
+		}
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("Invalid request", errMsg.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		before := auditedTag(r.Context(), tagRepository, id)
+		tag := structs.Tag{ID: id, Name: req.Name}
+		err = tagRepository.UpdateTag(r.Context(), &tag)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrTagNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("Tag not found"))
+			case errors.Is(err, storage.ErrTagExists):
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, response.Error("Tag already exists"))
+			default:
+				log.Error("Failed to update tag", errMsg.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Failed to update tag"))
+			}
+			return
+		}
+
+		log.Info("Tag renamed", slog.Int("tag_id", id))
+		auditor.Record(r.Context(), audit.Event{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityTag,
+			EntityID:   audit.ID(id),
+			Before:     before,
+			After:      tag,
+		})
+		responseOK(w, r, tag.Name, tag.ID)
+	}
+}
@@ -0,0 +1,275 @@
+package userhandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/paging"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/audit"
+	"banner-serivce/internal/auth"
+	"banner-serivce/internal/auth/jwt"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultUsersLimit = 100
+	maxUsersLimit     = 1000
+)
+
+type RequestGetUsers struct {
+	Role     string `json:"role"`
+	Disabled *bool  `json:"disabled"`
+	paging.Page
+}
+
+// RequestUpdateUser changes the role of a user or disables the account.
+// Fields left out are kept.
+type RequestUpdateUser struct {
+	Role     *string `json:"role"`
+	Disabled *bool   `json:"disabled"`
+}
+
+func NewGetUsersHandler(log *slog.Logger, userRepository User) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.users.getUsers.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		req, err := parseGetUsersRequest(r)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		users, err := userRepository.FindUsers(r.Context(), req)
+		if err != nil {
+			log.Error("Failed to get users", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get users"))
+			return
+		}
+
+		render.JSON(w, r, users)
+	}
+}
+
+func NewGetUserHandler(log *slog.Logger, userRepository User) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.users.getUser.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid user ID"))
+			return
+		}
+
+		user, err := userRepository.FindUserById(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, storage.ErrUserNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("User not found"))
+				return
+			}
+			log.Error("Failed to get user", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to get user"))
+			return
+		}
+
+		render.JSON(w, r, user)
+	}
+}
+
+// NewUpdateUserHandler changes the role or the disabled flag of a user. The
+// access tokens the user already holds are revoked when its role changes or
+// the account is disabled, so the change applies at once. Admins cannot
+// demote or disable themselves.
+func NewUpdateUserHandler(log *slog.Logger, userRepository User, jwtManager *jwt.JWTManager, ttl TokenTTL, auditor Auditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.users.updateUser.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid user ID"))
+			return
+		}
+
+		var req RequestUpdateUser
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", errMsg.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Failed to decode request"))
+			return
+		}
+		if req.Role != nil && *req.Role != auth.RoleAdmin && *req.Role != auth.RoleUser {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("role must be "+auth.RoleAdmin+" or "+auth.RoleUser))
+			return
+		}
+
+		before, err := userRepository.FindUserById(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, storage.ErrUserNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("User not found"))
+				return
+			}
+			log.Error("Failed to get user", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to update user"))
+			return
+		}
+
+		user := before
+		if req.Role != nil {
+			user.Role = *req.Role
+		}
+		if req.Disabled != nil {
+			user.Disabled = *req.Disabled
+		}
+
+		if isCurrentUser(r.Context(), before.Username) && (user.Disabled || user.Role != before.Role) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("You cannot disable your own account or change its role"))
+			return
+		}
+
+		var revocation *structs.UserRevocation
+		if (user.Disabled && !before.Disabled) || user.Role != before.Role {
+			revocation = newUserRevocation(ttl)
+		}
+
+		err = userRepository.UpdateUser(r.Context(), &user, revocation)
+		if err != nil {
+			if errors.Is(err, storage.ErrUserNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("User not found"))
+				return
+			}
+			log.Error("Failed to update user", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to update user"))
+			return
+		}
+
+		log.Info("User updated", slog.Int("user_id", id), slog.String("role", user.Role), slog.Bool("disabled", user.Disabled))
+		auditor.Record(r.Context(), audit.Event{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityUser,
+			EntityID:   audit.ID(id),
+			Before:     before,
+			After:      user,
+		})
+		if revocation != nil {
+			jwtManager.ApplyUserRevocation(revocation.Username, revocation.RevokedAt)
+		}
+
+		render.JSON(w, r, user)
+	}
+}
+
+// NewDeleteUserHandler deletes a user and revokes its tokens. Admins cannot
+// delete themselves.
+func NewDeleteUserHandler(log *slog.Logger, userRepository User, jwtManager *jwt.JWTManager, ttl TokenTTL, auditor Auditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.users.deleteUser.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Invalid user ID"))
+			return
+		}
+
+		user, err := userRepository.FindUserById(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, storage.ErrUserNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("User not found"))
+				return
+			}
+			log.Error("Failed to get user", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to delete user"))
+			return
+		}
+
+		if isCurrentUser(r.Context(), user.Username) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("You cannot delete your own account"))
+			return
+		}
+
+		revocation := newUserRevocation(ttl)
+		err = userRepository.DeleteUser(r.Context(), id, revocation)
+		if err != nil {
+			if errors.Is(err, storage.ErrUserNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("User not found"))
+				return
+			}
+			log.Error("Failed to delete user", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to delete user"))
+			return
+		}
+
+		log.Info("User deleted", slog.Int("user_id", id))
+		auditor.Record(r.Context(), audit.Event{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityUser,
+			EntityID:   audit.ID(id),
+			Before:     user,
+		})
+		jwtManager.ApplyUserRevocation(revocation.Username, revocation.RevokedAt)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// newUserRevocation refuses the access tokens issued so far. They all expire
+// within ttl.Access.
+func newUserRevocation(ttl TokenTTL) *structs.UserRevocation {
+	now := time.Now()
+	return &structs.UserRevocation{RevokedAt: now, ExpiresAt: now.Add(ttl.Access)}
+}
+
+func isCurrentUser(ctx context.Context, username string) bool {
+	principal, ok := jwt.PrincipalFromContext(ctx)
+	return ok && principal.Username == username
+}
+
+func parseGetUsersRequest(r *http.Request) (RequestGetUsers, error) {
+	req := RequestGetUsers{Role: r.URL.Query().Get("role")}
+
+	if disabledStr := r.URL.Query().Get("disabled"); disabledStr != "" {
+		disabled, err := strconv.ParseBool(disabledStr)
+		if err != nil {
+			return RequestGetUsers{}, errors.New("invalid disabled")
+		}
+		req.Disabled = &disabled
+	}
+
+	page, err := paging.Parse(r.URL.Query(), defaultUsersLimit, maxUsersLimit)
+	if err != nil {
+		return RequestGetUsers{}, err
+	}
+	req.Page = page
+
+	return req, nil
+}
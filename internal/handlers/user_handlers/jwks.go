@@ -0,0 +1,21 @@
+package userhandlers
+
+import (
+	"banner-serivce/internal/auth/jwt"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+type KeySource interface {
+	JWKS() jwt.JWKS
+}
+
+// NewJWKSHandler publishes the public keys tokens are signed with, so other
+// services can verify them without calling us.
+func NewJWKSHandler(keys KeySource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		render.JSON(w, r, keys.JWKS())
+	}
+}
@@ -2,10 +2,11 @@ package userhandlers
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
 	"banner-serivce/internal/api/response"
 	"banner-serivce/internal/auth"
 	"banner-serivce/internal/auth/jwt"
-	"github.com/go-chi/chi/v5/middleware"
+	"banner-serivce/internal/structs"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 	"log/slog"
@@ -15,18 +16,19 @@ import (
 
 type ResponseAuthUser struct {
 	response.Response
-	ID    int    `json:"user_id"`
-	Name  string `json:"name"`
-	Role  string `json:"role"`
-	Token string `json:"token"`
+	ID           int    `json:"user_id"`
+	Name         string `json:"name"`
+	Role         string `json:"role"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is the lifetime of Token in seconds.
+	ExpiresIn int `json:"expires_in"`
 }
 
-func LoginFunc(log *slog.Logger, userRepository User, jwt *jwt.JWTManager) http.HandlerFunc {
+func LoginFunc(log *slog.Logger, userRepository User, tokens RefreshTokens, jwtManager *jwt.JWTManager, ttl TokenTTL) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const loggerOptions = "handlers.features.createUser.New"
-		log = log.With(
-			slog.String("options", loggerOptions),
-			slog.String("request_id", middleware.GetReqID(r.Context())))
+		log := requestlog.New(log, r, loggerOptions)
 
 		var req RequestUser
 		err := render.DecodeJSON(r.Body, &req)
@@ -35,7 +37,7 @@ func LoginFunc(log *slog.Logger, userRepository User, jwt *jwt.JWTManager) http.
 			render.JSON(w, r, response.Error("Failed to decode request"))
 			return
 		}
-		log.Info("request body decoded", slog.Any("request", req))
+		log.Info("request body decoded", slog.String("username", req.Username))
 		if err := validator.New().Struct(req); err != nil {
 			validateErr := err.(validator.ValidationErrors)
 			log.Error("Invalid request", errMsg.Err(err))
@@ -56,12 +58,31 @@ func LoginFunc(log *slog.Logger, userRepository User, jwt *jwt.JWTManager) http.
 			render.JSON(w, r, response.Error("Invalid password"))
 			return
 		}
-		token, err := jwt.GenerateToken(user.Username, user.Role, time.Second*600)
+		if user.Disabled {
+			log.Info("Disabled user refused", slog.Int("user_id", user.ID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("User is disabled"))
+			return
+		}
+		token, err := jwtManager.GenerateToken(user.Username, user.Role, ttl.Access)
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to issue token"))
+			return
+		}
+		refreshToken, err := issueRefreshToken(r.Context(), tokens, user.ID, ttl.Refresh)
+		if err != nil {
+			log.Error("Failed to issue refresh token", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to issue token"))
+			return
+		}
 		log.Info("User authenticated")
-		responseAuthOK(w, r, req.Username, user.ID, user.Role, token)
+		responseAuthOK(w, r, user, token, refreshToken, ttl.Access)
 	}
 }
-func responseAuthOK(w http.ResponseWriter, r *http.Request, name string, userID int, role string, token string) {
+func responseAuthOK(w http.ResponseWriter, r *http.Request, user structs.User, token, refreshToken string, accessTTL time.Duration) {
 	render.JSON(w, r, ResponseAuthUser{Response: response.OK(),
-		Name: name, ID: userID, Role: role, Token: token})
+		Name: user.Username, ID: user.ID, Role: user.Role, Token: token,
+		RefreshToken: refreshToken, ExpiresIn: int(accessTTL.Seconds())})
 }
@@ -0,0 +1,177 @@
+package userhandlers
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
+	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/auth/jwt"
+	"banner-serivce/internal/storage"
+	"banner-serivce/internal/structs"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+)
+
+type RefreshTokens interface {
+	CreateRefreshToken(ctx context.Context, token *structs.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, tokenHash string, next *structs.RefreshToken) error
+	RevokeRefreshTokenFamily(ctx context.Context, tokenHash string) error
+}
+
+// TokenTTL is how long issued access and refresh tokens stay valid.
+type TokenTTL struct {
+	Access  time.Duration
+	Refresh time.Duration
+}
+
+type RequestRefreshToken struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// NewRefreshTokenHandler exchanges a refresh token for a new access token and
+// a new refresh token. The old refresh token cannot be used again.
+func NewRefreshTokenHandler(log *slog.Logger, userRepository User, tokens RefreshTokens, jwtManager *jwt.JWTManager, ttl TokenTTL) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.users.refreshToken.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		var req RequestRefreshToken
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", errMsg.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Failed to decode request"))
+			return
+		}
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		refreshToken, hash, err := jwt.NewRefreshToken()
+		if err != nil {
+			log.Error("Failed to generate refresh token", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to refresh token"))
+			return
+		}
+		next := structs.RefreshToken{TokenHash: hash, ExpiresAt: time.Now().Add(ttl.Refresh)}
+
+		err = tokens.RotateRefreshToken(r.Context(), jwt.HashRefreshToken(req.RefreshToken), &next)
+		if err != nil {
+			if errors.Is(err, storage.ErrRefreshTokenInvalid) || errors.Is(err, storage.ErrRefreshTokenReused) {
+				log.Info("Refresh token refused", errMsg.Err(err))
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, response.Error("Invalid refresh token"))
+				return
+			}
+			log.Error("Failed to rotate refresh token", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to refresh token"))
+			return
+		}
+
+		user, err := userRepository.FindUserById(r.Context(), next.UserID)
+		if err != nil {
+			if errors.Is(err, storage.ErrUserNotFound) {
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, response.Error("Invalid refresh token"))
+				return
+			}
+			log.Error("Failed to find user", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to refresh token"))
+			return
+		}
+		if user.Disabled {
+			log.Info("Disabled user refused", slog.Int("user_id", user.ID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("User is disabled"))
+			return
+		}
+
+		token, err := jwtManager.GenerateToken(user.Username, user.Role, ttl.Access)
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to refresh token"))
+			return
+		}
+
+		log.Info("Token refreshed", slog.Int("user_id", user.ID))
+		responseAuthOK(w, r, user, token, refreshToken, ttl.Access)
+	}
+}
+
+// NewLogoutHandler revokes the refresh token family of the session and, when
+// the request carries a bearer token, that access token as well.
+func NewLogoutHandler(log *slog.Logger, tokens RefreshTokens, jwtManager *jwt.JWTManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const loggerOptions = "handlers.users.logout.New"
+		log := requestlog.New(log, r, loggerOptions)
+
+		var req RequestRefreshToken
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("Failed to decode request body", errMsg.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("Failed to decode request"))
+			return
+		}
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		err := tokens.RevokeRefreshTokenFamily(r.Context(), jwt.HashRefreshToken(req.RefreshToken))
+		if err != nil {
+			if errors.Is(err, storage.ErrRefreshTokenInvalid) {
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, response.Error("Invalid refresh token"))
+				return
+			}
+			log.Error("Failed to revoke refresh tokens", errMsg.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Failed to log out"))
+			return
+		}
+
+		if accessToken, ok := jwt.BearerToken(r); ok {
+			if err := jwtManager.RevokeToken(r.Context(), accessToken); err != nil {
+				log.Info("Access token not revoked", errMsg.Err(err))
+			}
+		}
+
+		log.Info("User logged out")
+		render.JSON(w, r, response.OK())
+	}
+}
+
+// issueRefreshToken starts a new refresh token family for the user.
+func issueRefreshToken(ctx context.Context, tokens RefreshTokens, userID int, ttl time.Duration) (string, error) {
+	refreshToken, hash, err := jwt.NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	family, err := jwt.NewTokenFamily()
+	if err != nil {
+		return "", err
+	}
+
+	err = tokens.CreateRefreshToken(ctx, &structs.RefreshToken{
+		UserID:    userID,
+		FamilyID:  family,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return refreshToken, nil
+}
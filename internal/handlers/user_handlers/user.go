@@ -2,11 +2,12 @@ package userhandlers
 
 import (
 	errMsg "banner-serivce/internal/api/err"
+	"banner-serivce/internal/api/requestlog"
 	"banner-serivce/internal/api/response"
+	"banner-serivce/internal/audit"
 	"banner-serivce/internal/auth"
 	"banner-serivce/internal/structs"
 	"context"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 	"log/slog"
@@ -16,6 +17,15 @@ import (
 type User interface {
 	CreateUser(ctx context.Context, user *structs.User) error
 	FindUserByName(ctx context.Context, name string) (structs.User, error)
+	FindUserById(ctx context.Context, id int) (structs.User, error)
+	FindUsers(ctx context.Context, params RequestGetUsers) ([]structs.User, error)
+	UpdateUser(ctx context.Context, user *structs.User, revocation *structs.UserRevocation) error
+	DeleteUser(ctx context.Context, id int, revocation *structs.UserRevocation) error
+}
+
+// Auditor records administrative changes, see audit.Recorder.
+type Auditor interface {
+	Record(ctx context.Context, event audit.Event)
 }
 
 type RequestUser struct {
@@ -30,12 +40,10 @@ type ResponseUser struct {
 	Role string `json:"role"`
 }
 
-func New(log *slog.Logger, userRepository User) http.HandlerFunc {
+func New(log *slog.Logger, userRepository User, auditor Auditor) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const loggerOptions = "handlers.features.createUser.New"
-		log = log.With(
-			slog.String("options", loggerOptions),
-			slog.String("request_id", middleware.GetReqID(r.Context())))
+		log := requestlog.New(log, r, loggerOptions)
 
 		var req RequestUser
 		err := render.DecodeJSON(r.Body, &req)
@@ -44,7 +52,7 @@ func New(log *slog.Logger, userRepository User) http.HandlerFunc {
 			render.JSON(w, r, response.Error("Failed to decode request"))
 			return
 		}
-		log.Info("request body decoded", slog.Any("request", req))
+		log.Info("request body decoded", slog.String("username", req.Username))
 		if err := validator.New().Struct(req); err != nil {
 			validateErr := err.(validator.ValidationErrors)
 			log.Error("Invalid request", errMsg.Err(err))
@@ -52,7 +60,7 @@ func New(log *slog.Logger, userRepository User) http.HandlerFunc {
 			return
 		}
 		hashPass, err := auth.HashPassword(req.Password)
-		user := structs.User{Username: req.Username, Password: hashPass, Role: "user"}
+		user := structs.User{Username: req.Username, Password: hashPass, Role: auth.RoleUser}
 		err = userRepository.CreateUser(r.Context(), &user)
 		if err != nil {
 			log.Error("Failed to create user", errMsg.Err(err))
@@ -60,6 +68,13 @@ func New(log *slog.Logger, userRepository User) http.HandlerFunc {
 			return
 		}
 		log.Info("User added")
+		auditor.Record(r.Context(), audit.Event{
+			Actor:      user.Username,
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityUser,
+			EntityID:   audit.ID(user.ID),
+			After:      user,
+		})
 		responseOK(w, r, req.Username, user.ID, user.Role)
 	}
 }
@@ -0,0 +1,83 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+type CheckFunc func(ctx context.Context) error
+
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Ready  bool                   `json:"-"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Probe runs the readiness checks of the service dependencies. Once Drain is
+// called it reports not-ready regardless of the checks, so the load balancer
+// stops routing traffic before the server shuts down.
+type Probe struct {
+	timeout  time.Duration
+	checks   map[string]CheckFunc
+	draining atomic.Bool
+}
+
+func NewProbe(timeout time.Duration) *Probe {
+	return &Probe{timeout: timeout, checks: make(map[string]CheckFunc)}
+}
+
+// AddCheck registers a dependency check. It must be called before the probe
+// is used by a handler.
+func (p *Probe) AddCheck(name string, check CheckFunc) {
+	p.checks[name] = check
+}
+
+func (p *Probe) Drain() {
+	p.draining.Store(true)
+}
+
+func (p *Probe) Check(ctx context.Context) Report {
+	report := Report{Ready: true, Checks: make(map[string]CheckResult, len(p.checks))}
+	if p.draining.Load() {
+		report.Ready = false
+		report.Checks["server"] = CheckResult{Status: StatusDraining}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range p.checks {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+			result := CheckResult{Status: StatusUp}
+			if err := check(ctx); err != nil {
+				result = CheckResult{Status: StatusDown, Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Ready = false
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
@@ -0,0 +1,296 @@
+package jobs
+
+import (
+	errMsg "banner-serivce/internal/api/err"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// maxAttempts is how many times a job is claimed before it is given up, so a
+// job that keeps killing its worker does not run forever.
+const maxAttempts = 3
+
+var ErrStopped = errors.New("job manager is stopped")
+
+type Job struct {
+	ID         string          `json:"job_id"`
+	Kind       string          `json:"kind"`
+	Params     json.RawMessage `json:"-"`
+	Status     Status          `json:"status"`
+	Total      int             `json:"total"`
+	Done       int             `json:"done"`
+	Attempts   int             `json:"-"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Handler does the work of one kind of job. params is what was passed to
+// Enqueue. It calls progress whenever the total amount of work or the amount
+// already done changes. A job interrupted by a shutdown or a crash is run
+// again from the start, so handlers must be safe to repeat.
+type Handler func(ctx context.Context, params json.RawMessage, progress func(total, done int)) error
+
+// Store keeps jobs in a database shared by every replica.
+type Store interface {
+	CreateJob(ctx context.Context, job *Job) error
+	FindJob(ctx context.Context, id string) (Job, error)
+	// ClaimJob marks the oldest queued job of one of kinds as running and
+	// returns it. A running job whose heartbeat is older than staleBefore
+	// was abandoned by its worker and is claimed again.
+	ClaimJob(ctx context.Context, kinds []string, staleBefore time.Time) (Job, bool, error)
+	// UpdateJobProgress stores the progress and refreshes the heartbeat.
+	UpdateJobProgress(ctx context.Context, id string, total, done int) error
+	FinishJob(ctx context.Context, job Job) error
+	RequeueJob(ctx context.Context, id string) error
+	DeleteFinishedJobs(ctx context.Context, finishedBefore time.Time) error
+}
+
+// Manager runs jobs on a fixed pool of background workers. Jobs live in the
+// store, so they can be polled on any replica and queued jobs survive a
+// restart. Each worker claims one job at a time and keeps its heartbeat
+// fresh; a job whose heartbeat is older than lease is taken over by another
+// worker. Finished jobs are deleted after the retention period.
+type Manager struct {
+	store        Store
+	log          *slog.Logger
+	workers      int
+	pollInterval time.Duration
+	lease        time.Duration
+	retention    time.Duration
+
+	handlers map[string]Handler
+	kinds    []string
+
+	wake     chan struct{}
+	stopping chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+func NewManager(store Store, log *slog.Logger, workers int, pollInterval, lease, retention time.Duration) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		store:        store,
+		log:          log,
+		workers:      workers,
+		pollInterval: pollInterval,
+		lease:        lease,
+		retention:    retention,
+		handlers:     make(map[string]Handler),
+		wake:         make(chan struct{}, 1),
+		stopping:     make(chan struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Register sets the handler running jobs of kind. It must be called before
+// Start.
+func (m *Manager) Register(kind string, handler Handler) {
+	m.handlers[kind] = handler
+	m.kinds = append(m.kinds, kind)
+}
+
+func (m *Manager) Start() {
+	for i := 0; i < m.workers; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+}
+
+// Stop refuses new jobs and waits for the running ones to finish. Once ctx is
+// done the running jobs are cancelled and put back in the queue for another
+// replica or the next start. Queued jobs stay in the store.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return nil
+	}
+	m.stopped = true
+	close(m.stopping)
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		m.cancel()
+		return nil
+	case <-ctx.Done():
+		m.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Enqueue stores a job of kind with params marshalled to JSON.
+func (m *Manager) Enqueue(ctx context.Context, kind string, params any) (Job, error) {
+	if _, ok := m.handlers[kind]; !ok {
+		return Job{}, fmt.Errorf("unknown job kind %q", kind)
+	}
+	m.mu.Lock()
+	stopped := m.stopped
+	m.mu.Unlock()
+	if stopped {
+		return Job{}, ErrStopped
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return Job{}, err
+	}
+
+	if err := m.store.DeleteFinishedJobs(ctx, time.Now().Add(-m.retention)); err != nil {
+		m.log.Error("failed to delete finished jobs", errMsg.Err(err))
+	}
+
+	job := Job{ID: id, Kind: kind, Params: rawParams, Status: StatusQueued, CreatedAt: time.Now()}
+	if err := m.store.CreateJob(ctx, &job); err != nil {
+		return Job{}, err
+	}
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (Job, error) {
+	return m.store.FindJob(ctx, id)
+}
+
+func (m *Manager) worker() {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-m.stopping:
+			return
+		default:
+		}
+
+		job, ok, err := m.store.ClaimJob(m.ctx, m.kinds, time.Now().Add(-m.lease))
+		if err != nil {
+			m.log.Error("failed to claim job", errMsg.Err(err))
+		}
+		if err != nil || !ok {
+			select {
+			case <-m.stopping:
+				return
+			case <-m.wake:
+			case <-time.After(m.pollInterval):
+			}
+			continue
+		}
+		m.execute(job)
+	}
+}
+
+func (m *Manager) execute(job Job) {
+	log := m.log.With(slog.String("job_id", job.ID), slog.String("kind", job.Kind))
+
+	if job.Attempts > maxAttempts {
+		log.Error("job given up", slog.Int("attempts", job.Attempts-1))
+		m.finish(log, job, fmt.Errorf("job was interrupted %d times, giving up", maxAttempts))
+		return
+	}
+
+	var mu sync.Mutex
+	total, done := job.Total, job.Done
+	stopHeartbeat := make(chan struct{})
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		ticker := time.NewTicker(m.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopHeartbeat:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				t, d := total, done
+				mu.Unlock()
+				if err := m.store.UpdateJobProgress(context.Background(), job.ID, t, d); err != nil {
+					log.Error("failed to store job progress", errMsg.Err(err))
+				}
+			}
+		}
+	}()
+
+	log.Info("job started", slog.Int("attempt", job.Attempts))
+	err := m.handlers[job.Kind](m.ctx, job.Params, func(t, d int) {
+		mu.Lock()
+		total, done = t, d
+		mu.Unlock()
+	})
+	close(stopHeartbeat)
+	<-heartbeatDone
+	job.Total, job.Done = total, done
+
+	if err != nil && m.ctx.Err() != nil {
+		if err := m.store.RequeueJob(context.Background(), job.ID); err != nil {
+			log.Error("failed to requeue interrupted job", errMsg.Err(err))
+			return
+		}
+		log.Info("job interrupted by shutdown, requeued")
+		return
+	}
+	if err != nil {
+		log.Error("job failed", errMsg.Err(err))
+	} else {
+		log.Info("job finished")
+	}
+	m.finish(log, job, err)
+}
+
+func (m *Manager) finish(log *slog.Logger, job Job, err error) {
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = StatusDone
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	}
+	if err := m.store.FinishJob(context.Background(), job); err != nil {
+		log.Error("failed to store finished job", errMsg.Err(err))
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBannerNotFound   = errors.New("banner not found")
+	ErrRevisionNotFound = errors.New("banner revision not found")
+	ErrVersionMismatch  = errors.New("banner version does not match")
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrTagExists        = errors.New("tag already exists")
+	ErrTagInUse         = errors.New("tag is used by banners")
+	ErrFeatureNotFound  = errors.New("feature not found")
+	ErrFeatureInUse     = errors.New("feature is used by banners")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrJobNotFound      = errors.New("job not found")
+
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+)
+
+var ErrBannerConflict = errors.New("feature and tag pair already belongs to another banner")
+
+// BannerConflictError reports the banner that already owns a feature and tag pair.
+type BannerConflictError struct {
+	FeatureID int
+	TagID     int
+	BannerID  int
+}
+
+func (e *BannerConflictError) Error() string {
+	return fmt.Sprintf("feature %d and tag %d already belong to banner %d", e.FeatureID, e.TagID, e.BannerID)
+}
+
+func (e *BannerConflictError) Unwrap() error {
+	return ErrBannerConflict
+}
+
+var ErrLastTag = errors.New("tag is the only tag of a banner")
+
+// LastTagError lists the banners that would be left without tags, and so
+// could no longer be served, if the tag were taken off them.
+type LastTagError struct {
+	TagID     int
+	BannerIDs []int
+}
+
+func (e *LastTagError) Error() string {
+	return fmt.Sprintf("tag %d is the only tag of banners %v", e.TagID, e.BannerIDs)
+}
+
+func (e *LastTagError) Unwrap() error {
+	return ErrLastTag
+}
+
+var ErrUnknownReferences = errors.New("banner references unknown feature or tags")
+
+// UnknownReferencesError lists the feature and tag ids of a banner that do
+// not exist.
+type UnknownReferencesError struct {
+	FeatureID *int
+	TagIDs    []int
+}
+
+func (e *UnknownReferencesError) Error() string {
+	msg := "unknown"
+	if e.FeatureID != nil {
+		msg += fmt.Sprintf(" feature %d", *e.FeatureID)
+	}
+	if len(e.TagIDs) > 0 {
+		if e.FeatureID != nil {
+			msg += " and"
+		}
+		msg += fmt.Sprintf(" tags %v", e.TagIDs)
+	}
+	return msg
+}
+
+func (e *UnknownReferencesError) Unwrap() error {
+	return ErrUnknownReferences
+}
@@ -1,15 +1,90 @@
 package structs
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
+// Banner is served to users while IsActive is set and the current time is
+// within [ActiveFrom, ActiveUntil). A nil bound leaves that side open. When
+// Variants is not empty, users get the content of one of them instead of
+// Content.
 type Banner struct {
-	ID        int                    `json:"banner_id"`
-	TagIDs    []int                  `json:"tag_ids"`
-	FeatureID int                    `json:"feature_id"`
-	Content   map[string]interface{} `json:"content"`
-	IsActive  bool                   `json:"is_active"`
-	CreatedAt time.Time              `json:"created_at"`
-	UpdatedAt time.Time              `json:"updated_at"`
+	ID          int                    `json:"banner_id"`
+	TagIDs      []int                  `json:"tag_ids"`
+	FeatureID   int                    `json:"feature_id"`
+	Content     map[string]interface{} `json:"content"`
+	IsActive    bool                   `json:"is_active"`
+	ActiveFrom  *time.Time             `json:"active_from"`
+	ActiveUntil *time.Time             `json:"active_until"`
+	Version     int                    `json:"version"`
+	Variants    []BannerVariant        `json:"variants,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// BannerVariant is an alternative content of a banner for A/B experiments.
+// Each variant gets a share of users proportional to its Weight.
+type BannerVariant struct {
+	ID       int                    `json:"variant_id"`
+	BannerID int                    `json:"banner_id"`
+	Content  map[string]interface{} `json:"content"`
+	Weight   int                    `json:"weight"`
+}
+
+const (
+	ScheduleLive     = "live"
+	ScheduleUpcoming = "upcoming"
+	ScheduleExpired  = "expired"
+)
+
+// IsLive reports whether the banner should be served to users at now.
+func (b Banner) IsLive(now time.Time) bool {
+	if !b.IsActive {
+		return false
+	}
+	if b.ActiveFrom != nil && now.Before(*b.ActiveFrom) {
+		return false
+	}
+	if b.ActiveUntil != nil && !now.Before(*b.ActiveUntil) {
+		return false
+	}
+	return true
+}
+
+// ScheduleInUTC returns the banner with its activation window in UTC.
+func (b Banner) ScheduleInUTC() Banner {
+	b.ActiveFrom = InUTC(b.ActiveFrom)
+	b.ActiveUntil = InUTC(b.ActiveUntil)
+	return b
+}
+
+// InUTC normalizes a timestamp given or stored with any offset, so responses
+// and ETags do not depend on the time zone used.
+func InUTC(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// FeatureTag is the slot a banner is shown in.
+type FeatureTag struct {
+	FeatureID int `json:"feature_id"`
+	TagID     int `json:"tag_id"`
+}
+
+type BannerRevision struct {
+	BannerID    int                    `json:"banner_id"`
+	Version     int                    `json:"version"`
+	TagIDs      []int                  `json:"tag_ids"`
+	FeatureID   int                    `json:"feature_id"`
+	Content     map[string]interface{} `json:"content"`
+	IsActive    bool                   `json:"is_active"`
+	ActiveFrom  *time.Time             `json:"active_from"`
+	ActiveUntil *time.Time             `json:"active_until"`
+	CreatedAt   time.Time              `json:"created_at"`
 }
 
 type BannerTag struct {
@@ -27,9 +102,43 @@ type Tag struct {
 	Name string `json:"name"`
 }
 
+// User is an account. Password holds the bcrypt hash and is never encoded.
 type User struct {
 	ID       int    `json:"user_id"`
 	Username string `json:"username"`
-	Password string `json:"password"`
+	Password string `json:"-"`
 	Role     string `json:"role"`
+	Disabled bool   `json:"disabled"`
+}
+
+// UserRevocation refuses the access tokens of a user issued up to
+// RevokedAt. It is kept until ExpiresAt, when the last of them has expired.
+type UserRevocation struct {
+	Username  string
+	RevokedAt time.Time
+	ExpiresAt time.Time
+}
+
+// RefreshToken is a stored refresh token. Only the hash of the token is kept.
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+// AuditEvent records one administrative change. Before and After hold the
+// entity as JSON; either is empty when the entity did not exist on that side
+// of the change.
+type AuditEvent struct {
+	ID         int64           `json:"event_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   *int            `json:"entity_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
 }
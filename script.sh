@@ -1,4 +1,4 @@
 #!/bin/bash
 export CONFIG_PATH=../config/local.yaml
 cd cmd
-go run main.go
\ No newline at end of file
+go run . "$@"
\ No newline at end of file